@@ -0,0 +1,162 @@
+package flags
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+// FeatureOverrides is a flag.Value collecting repeated `--feature key=value`
+// arguments. Values are parsed per getter like EnvOverrides, and NewClient
+// checks them against the variation types in the flag file.
+type FeatureOverrides struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func RegisterFeatureOverrides(fs *flag.FlagSet, name string) *FeatureOverrides {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := &FeatureOverrides{}
+	fs.Var(f, name, "override a feature flag as key=value, can be repeated")
+	return f
+}
+
+func (f *FeatureOverrides) String() string {
+	if f == nil {
+		return ""
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	pairs := make([]string, 0, len(f.values))
+	for k, v := range f.values {
+		pairs = append(pairs, k+"="+v)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f *FeatureOverrides) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" {
+		return fmt.Errorf("expected key=value but got %q", s)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.values == nil {
+		f.values = map[string]string{}
+	}
+	f.values[k] = v
+	return nil
+}
+
+func (f *FeatureOverrides) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.values)
+}
+
+func (f *FeatureOverrides) lookup(flag string) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	v, ok := f.values[flag]
+	return v, ok
+}
+
+func (f *FeatureOverrides) validate(file flagFile) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var errs []error
+	for k, raw := range f.values {
+		variations, ok := file.variations(k)
+		if !ok {
+			errs = append(errs, fmt.Errorf("feature %s is not in the flag file", k))
+			continue
+		}
+		if len(variations) == 0 {
+			continue
+		}
+		kind := overrideKind(variations)
+		if err := parseAs(kind, raw); err != nil {
+			errs = append(errs, fmt.Errorf("feature %s expects a %s: %w", k, kind, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// overrideKind is the kind of value an override of a flag with these
+// variations must parse as. Every variation of a flag has the same type, but
+// a number flag whose variations are all whole is read with GetInt.
+func overrideKind(variations map[string]any) string {
+	kind := ""
+	whole := true
+	for _, v := range variations {
+		kind = variationKind(v)
+		if f, ok := v.(float64); ok && f != math.Trunc(f) {
+			whole = false
+		}
+	}
+	if kind == "number" && whole {
+		return "whole number"
+	}
+	return kind
+}
+
+func parseAs(kind, raw string) error {
+	var err error
+	switch kind {
+	case "bool":
+		_, err = strconv.ParseBool(raw)
+	case "number":
+		_, err = strconv.ParseFloat(raw, 64)
+	case "whole number":
+		_, err = strconv.Atoi(raw)
+	case "array":
+		_, err = parseJSON[[]any](raw)
+	case "object":
+		_, err = parseJSON[map[string]any](raw)
+	}
+	return err
+}
+
+func (f *FeatureOverrides) BoolVariationDetails(flag string, _ ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	raw, ok := f.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, strconv.ParseBool)
+}
+
+func (f *FeatureOverrides) IntVariationDetails(flag string, _ ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	raw, ok := f.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, strconv.Atoi)
+}
+
+func (f *FeatureOverrides) Float64VariationDetails(flag string, _ ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	raw, ok := f.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseFloat)
+}
+
+func (f *FeatureOverrides) StringVariationDetails(flag string, _ ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	raw, ok := f.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseString)
+}
+
+func (f *FeatureOverrides) JSONVariationDetails(flag string, _ ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	raw, ok := f.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseJSON[map[string]any])
+}
+
+func (f *FeatureOverrides) JSONArrayVariationDetails(flag string, _ ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	raw, ok := f.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseJSON[[]any])
+}
+
+var _ flag.Value = (*FeatureOverrides)(nil)
@@ -0,0 +1,184 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+	"github.com/thomaspoignant/go-feature-flag/utils/fflog"
+)
+
+func parseFeatures(t *testing.T, args ...string) *FeatureOverrides {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f := RegisterFeatureOverrides(fs, "feature")
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("unexpected error parsing args: %v", err)
+	}
+	return f
+}
+
+func TestFeatureOverrides(t *testing.T) {
+	f := parseFeatures(t, "--feature", "ff-number=42", "--feature=is-enabled=false", "--feature", `ff-json={"p50":1}`)
+	if f.Len() != 3 {
+		t.Fatalf("unexpected overrides: got %d want %d", f.Len(), 3)
+	}
+	if got, want := f.String(), `ff-json={"p50":1},ff-number=42,is-enabled=false`; got != want {
+		t.Errorf("unexpected string: got %s want %s", got, want)
+	}
+
+	l := NewLayered(setupClient(t, yamlFlagFileName), f)
+	i, err := GetInt(numberFlagName, "1", 69, l)
+	if err != nil || i != 42 {
+		t.Errorf("unexpected int: got %d, %v want %d", i, err, 42)
+	}
+	b, err := IsEnabled(isEnabledFlagName, "1", true, l)
+	if err != nil || b {
+		t.Errorf("unexpected bool: got %t, %v want %t", b, err, false)
+	}
+	s, err := GetString(descriptionFlagName, "1", "hello", l)
+	if err != nil || s != "Something about chocolate eggs" {
+		t.Errorf("expected fall through to file: got %s, %v", s, err)
+	}
+
+	t.Run("missing separator is rejected", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		RegisterFeatureOverrides(fs, "feature")
+		if err := fs.Parse([]string{"--feature", "ff-number"}); err == nil {
+			t.Errorf("expected error but got nil")
+		}
+	})
+}
+
+func TestFeatureOverridesValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		expectErr bool
+	}{
+		{"matching types", []string{"--feature", "ff-number=42", "--feature", "is-enabled=false", "--feature", "ff-json-list=[4]"}, false},
+		{"unknown flag", []string{"--feature", "nope=1"}, true},
+		{"number flag given a string", []string{"--feature", "ff-number=lots"}, true},
+		{"whole number flag given a fraction", []string{"--feature", "ff-number=4.5"}, true},
+		{"fractional number flag given a fraction", []string{"--feature", "ff-float=2.5"}, false},
+		{"bool flag given a number", []string{"--feature", "is-enabled=2"}, true},
+		{"object flag given an array", []string{"--feature", "ff-json=[1]"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := Config{
+				PollingInterval: 10 * time.Minute,
+				Retrievers: []retriever.Retriever{
+					&fileretriever.Retriever{Path: yamlFlagFileName},
+				},
+				Overrides: []Evaluator{parseFeatures(t, tt.args...)},
+			}
+			err := validateOverrides(cfg, "yaml", slog.Default())
+			if tt.expectErr && err == nil {
+				t.Errorf("expected error but got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error validating overrides: %v", err)
+			}
+		})
+	}
+
+	t.Run("NewClient rejects invalid overrides", func(t *testing.T) {
+		err := NewClient(Config{
+			DisableEnvOverrides: true,
+			Retrievers: []retriever.Retriever{
+				&fileretriever.Retriever{Path: jsonFlagFileName},
+			},
+			FileFormat: "json",
+			Overrides:  []Evaluator{parseFeatures(t, "--feature", "ff-number=lots")},
+		})
+		if err == nil {
+			t.Errorf("expected error but got nil")
+		}
+	})
+}
+
+func TestFeatureOverridesBeatEnv(t *testing.T) {
+	t.Setenv("FLAGS_OVERRIDE_FF_NUMBER", "7")
+	t.Setenv("FLAGS_OVERRIDE_IS_ENABLED", "true")
+	mem := NewInMemory(map[string]any{numberFlagName: 1, isEnabledFlagName: true})
+	err := NewClient(Config{
+		Evaluator: NewInMemory(map[string]any{numberFlagName: 9081, isEnabledFlagName: false}),
+		Overrides: []Evaluator{parseFeatures(t, "--feature", "is-enabled=false"), mem},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	// flags > env > other overrides > base
+	if b, err := IsEnabled(isEnabledFlagName, "1", true); err != nil || b {
+		t.Errorf("expected the command line to beat the env: got %t, %v", b, err)
+	}
+	if i, err := GetInt(numberFlagName, "1", 69); err != nil || i != 7 {
+		t.Errorf("expected the env to beat the other overrides: got %d, %v", i, err)
+	}
+}
+
+// initRetriever fails to retrieve until it is initialised, like goff's
+// cloud retrievers.
+type initRetriever struct {
+	retriever.Retriever
+	ready atomic.Bool
+}
+
+func (r *initRetriever) Init(context.Context, *fflog.FFLogger) error {
+	r.ready.Store(true)
+	return nil
+}
+
+func (r *initRetriever) Shutdown(context.Context) error {
+	r.ready.Store(false)
+	return nil
+}
+
+func (r *initRetriever) Status() retriever.Status {
+	if r.ready.Load() {
+		return retriever.RetrieverReady
+	}
+	return retriever.RetrieverNotReady
+}
+
+func (r *initRetriever) Retrieve(ctx context.Context) ([]byte, error) {
+	if !r.ready.Load() {
+		return nil, errors.New("client is not initialized")
+	}
+	return r.Retriever.Retrieve(ctx)
+}
+
+func TestFeatureOverridesInitializableRetriever(t *testing.T) {
+	ffclient.Close()
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Minute,
+		Retrievers:          []retriever.Retriever{&initRetriever{Retriever: &fileretriever.Retriever{Path: yamlFlagFileName}}},
+		Overrides:           []Evaluator{parseFeatures(t, "--feature", "ff-number=42")},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 42 {
+		t.Errorf("unexpected value: got %d, %v want 42", got, err)
+	}
+	if got, err := GetString(descriptionFlagName, "user", ""); err != nil || got == "" {
+		t.Errorf("expected the flags from the retriever, got %q, %v", got, err)
+	}
+}
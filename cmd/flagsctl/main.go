@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/valxntine/flags"
+)
+
+const usage = `usage: flagsctl <command> [flags]
+
+commands:
+  resolve   print a flag file merged with its environment overlay
+  keygen    create an ed25519 key pair for signing flag files
+  sign      write the detached signature of a flag file
+  verify    check a flag file against its detached signature
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errors.New("missing command")
+	}
+	switch args[0] {
+	case "resolve":
+		return resolve(args[1:], stdout, stderr)
+	case "keygen":
+		return keygen(args[1:], stdout, stderr)
+	case "sign":
+		return sign(args[1:], stdout, stderr)
+	case "verify":
+		return verify(args[1:], stdout, stderr)
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func resolve(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: flagsctl resolve [-env name] [-format yaml|json|toml] flags.goff.yaml")
+		fs.PrintDefaults()
+	}
+	env := fs.String("env", "", "environment overlay to apply, e.g. staging")
+	format := fs.String("format", "", "output format, defaults to the base file's")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("resolve expects exactly 1 flag file")
+	}
+
+	b, err := flags.ResolveOverlay(fs.Arg(0), *env, *format)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(b)
+	return err
+}
+
+func keygen(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("out", "flags.key", "file to write the private key to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(priv) + "\n"
+	if err = os.WriteFile(*out, []byte(key), 0o600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	_, err = fmt.Fprintln(stdout, base64.StdEncoding.EncodeToString(pub))
+	return err
+}
+
+func sign(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	keyPath := fs.String("key", "flags.key", "file with the base64 private key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("sign expects at least 1 flag file")
+	}
+	b, err := os.ReadFile(*keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %w", err)
+	}
+	key, err := flags.ParsePrivateKey(string(b))
+	if err != nil {
+		return err
+	}
+	for _, path := range fs.Args() {
+		doc, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read flag file: %w", err)
+		}
+		if err = os.WriteFile(path+flags.SignatureExt, flags.SignFlagFile(key, doc), 0o644); err != nil {
+			return fmt.Errorf("failed to write signature: %w", err)
+		}
+		fmt.Fprintf(stdout, "signed %s\n", path)
+	}
+	return nil
+}
+
+func verify(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var keys []ed25519.PublicKey
+	fs.Func("pub", "trusted base64 public key, repeat for key rotation", func(s string) error {
+		k, err := flags.ParsePublicKey(s)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || len(keys) == 0 {
+		return errors.New("verify expects a flag file and at least 1 -pub key")
+	}
+	path := fs.Arg(0)
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read flag file: %w", err)
+	}
+	sig, err := os.ReadFile(path + flags.SignatureExt)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	if err = flags.VerifyFlagFile(doc, sig, keys); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	_, err = fmt.Fprintf(stdout, "%s: ok\n", path)
+	return err
+}
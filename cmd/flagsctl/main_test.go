@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	files := map[string]string{
+		"flags.goff.yaml":      "a:\n  variations:\n    on: true\n    off: false\n  defaultRule:\n    variation: off\n",
+		"flags.prod.goff.yaml": "a:\n  defaultRule:\n    variation: on\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	tests := []struct {
+		name      string
+		args      []string
+		want      string
+		expectErr bool
+	}{
+		{
+			name: "prod",
+			args: []string{"resolve", "-env", "prod", filepath.Join(dir, "flags.goff.yaml")},
+			want: "variation: \"on\"",
+		},
+		{
+			name: "json",
+			args: []string{"resolve", "-env", "prod", "-format", "json", filepath.Join(dir, "flags.goff.yaml")},
+			want: `"defaultRule":{"variation":"on"}`,
+		},
+		{name: "no file", args: []string{"resolve"}, expectErr: true},
+		{name: "unknown command", args: []string{"nope"}, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr bytes.Buffer
+			err := run(tt.args, &stdout, &stderr)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("expected %q in output:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	key := filepath.Join(dir, "flags.key")
+	flagFile := filepath.Join(dir, "flags.goff.yaml")
+	if err := os.WriteFile(flagFile, []byte("a:\n  variations:\n    on: true\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var pub, stderr bytes.Buffer
+	if err := run([]string{"keygen", "-out", key}, &pub, &stderr); err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	if err := run([]string{"sign", "-key", key, flagFile}, io.Discard, &stderr); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	verifyArgs := []string{"verify", "-pub", strings.TrimSpace(pub.String()), flagFile}
+	if err := run(verifyArgs, io.Discard, &stderr); err != nil {
+		t.Fatalf("unexpected error verifying: %v", err)
+	}
+
+	if err := os.WriteFile(flagFile, []byte("a:\n  variations:\n    on: false\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := run(verifyArgs, io.Discard, &stderr); err == nil {
+		t.Errorf("expected a tampered flag file to fail verification")
+	}
+}
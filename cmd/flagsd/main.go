@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+	"github.com/valxntine/flags"
+)
+
+type config struct {
+	Addr            string   `json:"addr"`
+	FlagFiles       []string `json:"flagFiles"`
+	FileFormat      string   `json:"fileFormat"`
+	PollingInterval string   `json:"pollingInterval"`
+}
+
+func main() {
+	path := flag.String("config", os.Getenv("FLAGSD_CONFIG"), "path to a JSON config file")
+	flag.Parse()
+
+	cfg, err := loadConfig(*path)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err = run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadConfig(path string) (config, error) {
+	cfg := config{
+		Addr:            ":8080",
+		FileFormat:      "yaml",
+		PollingInterval: "60s",
+	}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		if err = json.Unmarshal(b, &cfg); err != nil {
+			return cfg, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	// env vars take precedence over the config file
+	if v := os.Getenv("FLAGSD_ADDR"); v != "" {
+		cfg.Addr = v
+	}
+	if v := os.Getenv("FLAGSD_FLAG_FILES"); v != "" {
+		cfg.FlagFiles = strings.Split(v, ",")
+	}
+	if v := os.Getenv("FLAGSD_FILE_FORMAT"); v != "" {
+		cfg.FileFormat = v
+	}
+	if v := os.Getenv("FLAGSD_POLLING_INTERVAL"); v != "" {
+		cfg.PollingInterval = v
+	}
+
+	if len(cfg.FlagFiles) == 0 {
+		return cfg, errors.New("at least 1 flag file is required")
+	}
+	return cfg, nil
+}
+
+func (c config) flagsConfig() (flags.Config, error) {
+	interval, err := time.ParseDuration(c.PollingInterval)
+	if err != nil {
+		return flags.Config{}, fmt.Errorf("failed to parse polling interval %s: %w", c.PollingInterval, err)
+	}
+	retrievers := make([]retriever.Retriever, 0, len(c.FlagFiles))
+	for _, f := range c.FlagFiles {
+		f = strings.TrimSpace(f)
+		if strings.HasPrefix(f, "http://") || strings.HasPrefix(f, "https://") {
+			retrievers = append(retrievers, &flags.HTTPRetriever{URL: f})
+			continue
+		}
+		retrievers = append(retrievers, &fileretriever.Retriever{Path: f})
+	}
+	return flags.Config{
+		PollingInterval: interval,
+		Retrievers:      retrievers,
+		FileFormat:      c.FileFormat,
+	}, nil
+}
+
+func run(cfg config) error {
+	fc, err := cfg.flagsConfig()
+	if err != nil {
+		return err
+	}
+	if err = flags.NewClient(fc); err != nil {
+		return err
+	}
+	defer flags.Close()
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           newServer(nil).routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("flagsd listening on %s", cfg.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
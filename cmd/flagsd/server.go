@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"github.com/valxntine/flags"
+	"github.com/valxntine/flags/remote"
+)
+
+const maxBodyBytes = 1 << 20
+
+type server struct {
+	client *ffclient.GoFeatureFlag
+
+	mu       sync.Mutex
+	requests map[string]int
+	errors   map[string]int
+}
+
+func newServer(client *ffclient.GoFeatureFlag) *server {
+	return &server{
+		client:   client,
+		requests: map[string]int{},
+		errors:   map[string]int{},
+	}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/evaluate", s.handleEvaluate)
+	mux.HandleFunc("POST /v1/evaluate/all", s.handleEvaluateAll)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.Handle("GET /debug/flags", flags.DebugHandler(s.client))
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return mux
+}
+
+func (s *server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	s.count("evaluate")
+	var req remote.EvaluateRequest
+	if err := decode(w, r, &req); err != nil {
+		s.fail(w, "evaluate", http.StatusBadRequest, err)
+		return
+	}
+	if req.Flag == "" {
+		s.fail(w, "evaluate", http.StatusBadRequest, errors.New("flag is required"))
+		return
+	}
+
+	res, err := s.evaluate(req)
+	if errors.Is(err, errBadRequest) {
+		s.fail(w, "evaluate", http.StatusBadRequest, err)
+		return
+	}
+	value, marshalErr := json.Marshal(res.value)
+	if marshalErr != nil {
+		s.fail(w, "evaluate", http.StatusInternalServerError, marshalErr)
+		return
+	}
+	resp := remote.EvaluateResponse{
+		Flag:    req.Flag,
+		Value:   value,
+		Variant: res.variant,
+		Reason:  res.reason,
+	}
+	if err != nil {
+		s.countError("evaluation")
+		resp.Error = err.Error()
+		resp.Code = errorCode(err)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) handleEvaluateAll(w http.ResponseWriter, r *http.Request) {
+	s.count("evaluate_all")
+	var req remote.EvaluateAllRequest
+	if err := decode(w, r, &req); err != nil {
+		s.fail(w, "evaluate_all", http.StatusBadRequest, err)
+		return
+	}
+	userID := req.UserID
+	if userID == "" {
+		userID = "anonymous"
+	}
+	writeJSON(w, http.StatusOK, s.evaluateAll(ffcontext.NewEvaluationContext(userID)))
+}
+
+// flagState is a flag in the /v1/evaluate/all response, the same as goff's
+// AllFlagsState.
+type flagState struct {
+	Value         any            `json:"value"`
+	Timestamp     int64          `json:"timestamp"`
+	VariationType string         `json:"variationType"`
+	TrackEvents   bool           `json:"trackEvents"`
+	ErrorCode     string         `json:"errorCode"`
+	Reason        string         `json:"reason"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+}
+
+type allFlags struct {
+	Flags map[string]flagState `json:"flags,omitempty"`
+	Valid bool                 `json:"valid"`
+}
+
+// evaluateAll evaluates every flag goff has the same way as /v1/evaluate,
+// with the overrides and hooks. goff's own state only gives the flags and
+// their types. A flag without a value, e.g. a disabled one, is reported as
+// goff has it.
+func (s *server) evaluateAll(c ffcontext.Context) allFlags {
+	allFlagsState := ffclient.AllFlagsState
+	if s.client != nil {
+		allFlagsState = s.client.AllFlagsState
+	}
+	state := allFlagsState(c)
+	e := flags.WithHooks(s.evaluator())
+	all := allFlags{Flags: map[string]flagState{}, Valid: state.IsValid()}
+	now := time.Now().Unix()
+	for name, fs := range state.GetFlags() {
+		var res result
+		var err error
+		switch v := fs.Value.(type) {
+		case bool:
+			res, err = details(e.BoolVariationDetails(name, c, v))
+		case int:
+			res, err = details(e.IntVariationDetails(name, c, v))
+		case float64:
+			res, err = details(e.Float64VariationDetails(name, c, v))
+		case string:
+			res, err = details(e.StringVariationDetails(name, c, v))
+		case map[string]any:
+			res, err = details(e.JSONVariationDetails(name, c, v))
+		case []any:
+			res, err = details(e.JSONArrayVariationDetails(name, c, v))
+		default:
+			all.Flags[name] = flagState{
+				Value:         fs.Value,
+				Timestamp:     fs.Timestamp,
+				VariationType: fs.VariationType,
+				TrackEvents:   fs.TrackEvents,
+				ErrorCode:     string(fs.ErrorCode),
+				Reason:        string(fs.Reason),
+				Metadata:      fs.Metadata,
+			}
+			continue
+		}
+		f := flagState{
+			Value:         res.value,
+			Timestamp:     now,
+			VariationType: res.variant,
+			TrackEvents:   fs.TrackEvents,
+			Reason:        res.reason,
+			Metadata:      res.metadata,
+		}
+		if err != nil {
+			f.ErrorCode = errorCode(err)
+			all.Valid = false
+		}
+		all.Flags[name] = f
+	}
+	return all
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	refreshed := s.refreshDate()
+	if refreshed.IsZero() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":    "ready",
+		"refreshed": refreshed.UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# TYPE flagsd_requests_total counter")
+	for _, k := range slices.Sorted(maps.Keys(s.requests)) {
+		fmt.Fprintf(w, "flagsd_requests_total{endpoint=%q} %d\n", k, s.requests[k])
+	}
+	fmt.Fprintln(w, "# TYPE flagsd_errors_total counter")
+	for _, k := range slices.Sorted(maps.Keys(s.errors)) {
+		fmt.Fprintf(w, "flagsd_errors_total{kind=%q} %d\n", k, s.errors[k])
+	}
+	fmt.Fprintln(w, "# TYPE flagsd_cache_refresh_timestamp_seconds gauge")
+	var ts int64
+	if refreshed := s.refreshDate(); !refreshed.IsZero() {
+		ts = refreshed.Unix()
+	}
+	fmt.Fprintf(w, "flagsd_cache_refresh_timestamp_seconds %d\n", ts)
+}
+
+var errBadRequest = errors.New("bad request")
+
+type result struct {
+	value    any
+	variant  string
+	reason   string
+	metadata map[string]any
+}
+
+func details[T model.JSONType](res model.VariationResult[T], err error) (result, error) {
+	return result{
+		value:    res.Value,
+		variant:  res.VariationType,
+		reason:   res.Reason,
+		metadata: res.Metadata,
+	}, err
+}
+
+// evaluate runs every type through the configured hooks, an evaluation
+// error is a *flags.EvaluationError.
+func (s *server) evaluate(req remote.EvaluateRequest) (result, error) {
+	e := flags.WithHooks(s.evaluator())
+	c, err := evaluationContext(req)
+	if err != nil {
+		return result{}, err
+	}
+	switch req.Type {
+	case remote.TypeBool, "":
+		var def bool
+		if err = decodeDefault(req.Default, &def); err != nil {
+			return result{}, err
+		}
+		return details(e.BoolVariationDetails(req.Flag, c, def))
+	case remote.TypeInt:
+		var def int
+		if err = decodeDefault(req.Default, &def); err != nil {
+			return result{}, err
+		}
+		return details(e.IntVariationDetails(req.Flag, c, def))
+	case remote.TypeFloat:
+		var def float64
+		if err = decodeDefault(req.Default, &def); err != nil {
+			return result{}, err
+		}
+		return details(e.Float64VariationDetails(req.Flag, c, def))
+	case remote.TypeString:
+		var def string
+		if err = decodeDefault(req.Default, &def); err != nil {
+			return result{}, err
+		}
+		return details(e.StringVariationDetails(req.Flag, c, def))
+	case remote.TypeJSON:
+		var def map[string]any
+		if err = decodeDefault(req.Default, &def); err != nil {
+			return result{}, err
+		}
+		return details(e.JSONVariationDetails(req.Flag, c, def))
+	case remote.TypeJSONArray:
+		var def []any
+		if err = decodeDefault(req.Default, &def); err != nil {
+			return result{}, err
+		}
+		return details(e.JSONArrayVariationDetails(req.Flag, c, def))
+	case remote.TypeTime:
+		layout := req.Layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		var raw string
+		if err = decodeDefault(req.Default, &raw); err != nil {
+			return result{}, err
+		}
+		var def time.Time
+		if raw != "" {
+			t, err := time.Parse(layout, raw)
+			if err != nil {
+				return result{}, fmt.Errorf("%w: failed to parse default %s into layout %s: %v", errBadRequest, raw, layout, err)
+			}
+			def = t
+		}
+		res, err := details(e.StringVariationDetails(req.Flag, c, def.Format(layout)))
+		if err != nil {
+			return res, err
+		}
+		v := res.value.(string)
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			res.value = def.Format(layout)
+			return res, fmt.Errorf("failed to parse time %s into layout %s: %w", v, layout, err)
+		}
+		res.value = t.Format(layout)
+		return res, nil
+	case remote.TypeIDList:
+		var def bool
+		if err = decodeDefault(req.Default, &def); err != nil {
+			return result{}, err
+		}
+		var id any
+		if err = decodeDefault(req.ID, &id); err != nil {
+			return result{}, err
+		}
+		switch id.(type) {
+		case float64, string:
+		default:
+			return result{}, fmt.Errorf("%w: id must be a number or a string", errBadRequest)
+		}
+		res, err := details(e.JSONArrayVariationDetails(req.Flag, c, []any{}))
+		if err != nil {
+			res.value = def
+			return res, err
+		}
+		res.value = slices.ContainsFunc(res.value.([]any), func(v any) bool {
+			// list entries from yaml may be ints, the id is a json number
+			if i, ok := v.(int); ok {
+				v = float64(i)
+			}
+			return v == id
+		})
+		return res, nil
+	default:
+		return result{}, fmt.Errorf("%w: unknown type %q", errBadRequest, req.Type)
+	}
+}
+
+func evaluationContext(req remote.EvaluateRequest) (ffcontext.Context, error) {
+	userID := req.UserID
+	if userID == "" {
+		userID = "anonymous"
+	}
+	b := ffcontext.NewEvaluationContextBuilder(userID)
+	for k, v := range req.Custom {
+		b.AddCustom(k, v)
+	}
+	if req.Lookup != "" {
+		var id any
+		if err := decodeDefault(req.ID, &id); err != nil {
+			return nil, err
+		}
+		b.AddCustom(req.Lookup, id)
+	}
+	return b.Build(), nil
+}
+
+func (s *server) evaluator() flags.Evaluator {
+	if s.client != nil {
+		return s.client
+	}
+	return flags.Default()
+}
+
+// errorCode is the goff error code of a failed evaluation, anything else,
+// such as a time in the wrong layout, is general.
+func errorCode(err error) string {
+	var evalErr *flags.EvaluationError
+	if errors.As(err, &evalErr) && evalErr.Code != "" {
+		return evalErr.Code
+	}
+	return remote.CodeGeneral
+}
+
+func (s *server) refreshDate() time.Time {
+	if s.client != nil {
+		return s.client.GetCacheRefreshDate()
+	}
+	return ffclient.GetCacheRefreshDate()
+}
+
+func (s *server) count(endpoint string) {
+	s.mu.Lock()
+	s.requests[endpoint]++
+	s.mu.Unlock()
+}
+
+func (s *server) countError(kind string) {
+	s.mu.Lock()
+	s.errors[kind]++
+	s.mu.Unlock()
+}
+
+func (s *server) fail(w http.ResponseWriter, endpoint string, status int, err error) {
+	s.countError(endpoint + "_bad_request")
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode request: %w", err)
+	}
+	return nil
+}
+
+func decodeDefault(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: failed to decode %s: %v", errBadRequest, raw, err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+	"github.com/valxntine/flags"
+	"github.com/valxntine/flags/remote"
+)
+
+func setupServer(t *testing.T) http.Handler {
+	t.Helper()
+	c, err := ffclient.New(ffclient.Config{
+		PollingInterval: 10 * time.Minute,
+		Retrievers: []retriever.Retriever{
+			&fileretriever.Retriever{Path: "../../flags.goff.yaml"},
+		},
+		FileFormat: "yaml",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return newServer(c).routes()
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		status    int
+		expected  any
+		expectErr bool
+	}{
+		{"bool flag", `{"flag":"is-enabled","type":"bool","default":false}`, http.StatusOK, true, false},
+		{"bool flag by id", `{"flag":"is-enabled-for-user","type":"bool","lookup":"user-id","id":"2","default":false}`, http.StatusOK, true, false},
+		{"int flag", `{"flag":"ff-number","type":"int","default":1}`, http.StatusOK, 9081., false},
+		{"float flag", `{"flag":"ff-float","type":"float","default":1.1}`, http.StatusOK, 3.14159, false},
+		{"string flag", `{"flag":"ff-description","type":"string","default":"hi"}`, http.StatusOK, "Something about chocolate eggs", false},
+		{"time flag", `{"flag":"cr-start","type":"time","default":"2099-12-31T23:59:59Z"}`, http.StatusOK, "2025-07-18T22:37:22Z", false},
+		{"id list flag", `{"flag":"ff-json-list","type":"id_list","id":2,"default":false}`, http.StatusOK, true, false},
+		{"flag doesnt exist, returns default", `{"flag":"not-exists","type":"int","default":69}`, http.StatusOK, 69., true},
+		{"unknown type", `{"flag":"ff-number","type":"nope"}`, http.StatusBadRequest, nil, true},
+		{"default of the wrong type", `{"flag":"ff-number","type":"int","default":"one"}`, http.StatusBadRequest, nil, true},
+		{"missing flag", `{"type":"int"}`, http.StatusBadRequest, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := setupServer(t)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/evaluate", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.status {
+				t.Fatalf("unexpected status: got %d want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var resp remote.EvaluateResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("unexpected error decoding response: %v", err)
+			}
+			var value any
+			if err := json.Unmarshal(resp.Value, &value); err != nil {
+				t.Fatalf("unexpected error decoding value: %v", err)
+			}
+			if tt.expectErr && resp.Error == "" {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectErr && resp.Error != "" {
+				t.Errorf("unexpected error: %s", resp.Error)
+			}
+			if diff := cmp.Diff(value, tt.expected); diff != "" {
+				t.Errorf("unexpected value (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEvaluateErrorCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"flag not found", `{"flag":"not-exists","type":"int","default":69}`, remote.CodeFlagNotFound},
+		{"type mismatch", `{"flag":"ff-description","type":"int","default":1}`, remote.CodeTypeMismatch},
+		{"time flag not found", `{"flag":"not-exists","type":"time"}`, remote.CodeFlagNotFound},
+		{"id list type mismatch", `{"flag":"ff-number","type":"id_list","id":1}`, remote.CodeTypeMismatch},
+		{"time in the wrong layout", `{"flag":"ff-description","type":"time"}`, remote.CodeGeneral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := setupServer(t)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/evaluate", strings.NewReader(tt.body)))
+
+			var resp remote.EvaluateResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("unexpected error decoding response: %v", err)
+			}
+			if resp.Code != tt.code {
+				t.Errorf("unexpected code: got %s want %s (%s)", resp.Code, tt.code, resp.Error)
+			}
+		})
+	}
+}
+
+type countingHook struct {
+	flags.BaseHook
+	mu    sync.Mutex
+	flags []string
+}
+
+func (h *countingHook) Finally(_ context.Context, hc flags.HookContext, _ flags.EvaluationDetails) {
+	h.mu.Lock()
+	h.flags = append(h.flags, hc.Flag)
+	h.mu.Unlock()
+}
+
+func TestEvaluateRunsHooks(t *testing.T) {
+	c, err := ffclient.New(ffclient.Config{
+		PollingInterval: 10 * time.Minute,
+		Retrievers: []retriever.Retriever{
+			&fileretriever.Retriever{Path: "../../flags.goff.yaml"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	hook := &countingHook{}
+	err = flags.NewClient(flags.Config{Evaluator: c, Hooks: []flags.Hook{hook}, DisableEnvOverrides: true})
+	if err != nil {
+		t.Fatalf("unexpected error installing client: %v", err)
+	}
+	defer flags.Close()
+
+	h := newServer(nil).routes()
+	bodies := []string{
+		`{"flag":"is-enabled","type":"bool"}`,
+		`{"flag":"ff-number","type":"int"}`,
+		`{"flag":"cr-start","type":"time"}`,
+		`{"flag":"ff-json-list","type":"id_list","id":2}`,
+	}
+	for _, body := range bodies {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/evaluate", strings.NewReader(body)))
+	}
+	want := []string{"is-enabled", "ff-number", "cr-start", "ff-json-list"}
+	if diff := cmp.Diff(hook.flags, want); diff != "" {
+		t.Errorf("expected hooks for every type (-got +want)\n%s", diff)
+	}
+}
+
+func TestEvaluateAll(t *testing.T) {
+	h := setupServer(t)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/evaluate/all", strings.NewReader(`{"userID":"1"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d want %d", rec.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Flags map[string]struct {
+			Value any `json:"value"`
+		} `json:"flags"`
+		Valid bool `json:"valid"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+	if !resp.Valid {
+		t.Errorf("expected all flags to be valid")
+	}
+	if got := resp.Flags["ff-description"].Value; got != "Something about chocolate eggs" {
+		t.Errorf("unexpected value: got %v want %s", got, "Something about chocolate eggs")
+	}
+}
+
+func TestProbesAndMetrics(t *testing.T) {
+	h := setupServer(t)
+	for _, path := range []string{"/healthz", "/readyz"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("unexpected status for %s: got %d want %d", path, rec.Code, http.StatusOK)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/evaluate", strings.NewReader(`{"flag":"not-exists"}`)))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`flagsd_requests_total{endpoint="evaluate"} 1`,
+		`flagsd_errors_total{kind="evaluation"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected metrics to contain %s, got:\n%s", want, rec.Body)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("FLAGSD_FLAG_FILES", "a.goff.yaml,https://example.com/flags.yaml")
+	t.Setenv("FLAGSD_POLLING_INTERVAL", "5s")
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	fc, err := cfg.flagsConfig()
+	if err != nil {
+		t.Fatalf("unexpected error building flags config: %v", err)
+	}
+	if fc.PollingInterval != 5*time.Second {
+		t.Errorf("unexpected polling interval: got %s want %s", fc.PollingInterval, 5*time.Second)
+	}
+	if len(fc.Retrievers) != 2 {
+		t.Errorf("unexpected retrievers: got %d want %d", len(fc.Retrievers), 2)
+	}
+}
+
+func TestEvaluateAllMatchesEvaluate(t *testing.T) {
+	t.Setenv("FLAGS_OVERRIDE_FF_NUMBER", "7")
+	ffclient.Close()
+	hook := &countingHook{}
+	err := flags.NewClient(flags.Config{
+		PollingInterval: 10 * time.Minute,
+		Retrievers: []retriever.Retriever{
+			&fileretriever.Retriever{Path: "../../flags.goff.yaml"},
+		},
+		Hooks: []flags.Hook{hook},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer flags.Close()
+	h := newServer(nil).routes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/evaluate", strings.NewReader(`{"flag":"ff-number","type":"int","userID":"1"}`)))
+	var one remote.EvaluateResponse
+	if err = json.NewDecoder(rec.Body).Decode(&one); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/evaluate/all", strings.NewReader(`{"userID":"1"}`)))
+	var all struct {
+		Flags map[string]struct {
+			Value  json.RawMessage `json:"value"`
+			Reason string          `json:"reason"`
+		} `json:"flags"`
+	}
+	if err = json.NewDecoder(rec.Body).Decode(&all); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+	got := all.Flags["ff-number"]
+	if string(got.Value) != "7" || string(one.Value) != "7" || got.Reason != one.Reason {
+		t.Errorf("expected the env override from both, got %s (%s) and %s (%s)", got.Value, got.Reason, one.Value, one.Reason)
+	}
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if len(hook.flags) != 1+len(all.Flags) {
+		t.Errorf("expected hooks for every flag, got %d evaluations for %d flags", len(hook.flags), len(all.Flags))
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+	"github.com/valxntine/flags"
+)
+
+type config struct {
+	addr            string
+	flagFiles       []string
+	fileFormat      string
+	pollingInterval time.Duration
+}
+
+func main() {
+	cfg, err := parseConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err = run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseConfig reads the command line, each flag defaults to its
+// FLAGSRELAY_* env var.
+func parseConfig(args []string) (config, error) {
+	fs := flag.NewFlagSet("flagsrelay", flag.ContinueOnError)
+	addr := fs.String("addr", envOr("FLAGSRELAY_ADDR", ":8080"), "address to listen on")
+	files := fs.String("flag-files", os.Getenv("FLAGSRELAY_FLAG_FILES"), "comma separated flag file paths or http(s) URLs")
+	format := fs.String("file-format", envOr("FLAGSRELAY_FILE_FORMAT", "yaml"), "flag file format")
+	interval := fs.String("polling-interval", envOr("FLAGSRELAY_POLLING_INTERVAL", "60s"), "how often to refresh the flags")
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	d, err := time.ParseDuration(*interval)
+	if err != nil {
+		return config{}, fmt.Errorf("failed to parse polling interval %s: %w", *interval, err)
+	}
+	if *files == "" {
+		return config{}, errors.New("at least 1 flag file is required")
+	}
+	return config{
+		addr:            *addr,
+		flagFiles:       strings.Split(*files, ","),
+		fileFormat:      *format,
+		pollingInterval: d,
+	}, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (c config) relayConfig() flags.RelayConfig {
+	retrievers := make([]retriever.Retriever, 0, len(c.flagFiles))
+	for _, f := range c.flagFiles {
+		f = strings.TrimSpace(f)
+		if strings.HasPrefix(f, "http://") || strings.HasPrefix(f, "https://") {
+			retrievers = append(retrievers, &flags.HTTPRetriever{URL: f})
+			continue
+		}
+		retrievers = append(retrievers, &fileretriever.Retriever{Path: f})
+	}
+	return flags.RelayConfig{
+		Retrievers:      retrievers,
+		FileFormat:      c.fileFormat,
+		PollingInterval: c.pollingInterval,
+	}
+}
+
+func routes(relay *flags.Relay) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/flags", relay)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok"}` + "\n"))
+	})
+	return mux
+}
+
+func run(cfg config) error {
+	relay, err := flags.NewRelay(cfg.relayConfig())
+	if err != nil {
+		return err
+	}
+	defer relay.Close()
+
+	srv := &http.Server{
+		Addr:              cfg.addr,
+		Handler:           routes(relay),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("flagsrelay listening on %s", cfg.addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+	"github.com/valxntine/flags"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		expected  config
+		expectErr bool
+	}{
+		{
+			name: "defaults",
+			args: []string{"--flag-files", "a.yaml, https://flags.example.com/flags.yaml"},
+			expected: config{
+				addr:            ":8080",
+				flagFiles:       []string{"a.yaml", " https://flags.example.com/flags.yaml"},
+				fileFormat:      "yaml",
+				pollingInterval: time.Minute,
+			},
+		},
+		{name: "no flag files", args: []string{}, expectErr: true},
+		{name: "bad interval", args: []string{"--flag-files", "a.yaml", "--polling-interval", "soon"}, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig(tt.args)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.addr != tt.expected.addr || cfg.fileFormat != tt.expected.fileFormat ||
+				cfg.pollingInterval != tt.expected.pollingInterval || len(cfg.flagFiles) != len(tt.expected.flagFiles) {
+				t.Errorf("unexpected config: got %+v want %+v", cfg, tt.expected)
+			}
+			rc := cfg.relayConfig()
+			if _, ok := rc.Retrievers[0].(*fileretriever.Retriever); !ok {
+				t.Errorf("expected a file retriever, got %T", rc.Retrievers[0])
+			}
+			if _, ok := rc.Retrievers[1].(*flags.HTTPRetriever); !ok {
+				t.Errorf("expected an http retriever, got %T", rc.Retrievers[1])
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	relay, err := flags.NewRelay(config{flagFiles: []string{"../../flags.goff.yaml"}}.relayConfig())
+	if err != nil {
+		t.Fatalf("unexpected error creating relay: %v", err)
+	}
+	t.Cleanup(relay.Close)
+	h := routes(relay)
+
+	for path, status := range map[string]int{"/v1/flags": http.StatusOK, "/healthz": http.StatusOK, "/nope": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != status {
+			t.Errorf("unexpected status for %s: got %d want %d", path, w.Code, status)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package flags
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+var errNotStarted = errors.New("flags client is not initialised")
+
+// cachedFlags returns the flags goff currently holds along with the time
+// they were last refreshed.
+func cachedFlags(client *ffclient.GoFeatureFlag) (flagFile, time.Time, error) {
+	defaultMu.RLock()
+	started := goffStarted
+	defaultMu.RUnlock()
+	if client == nil && !started {
+		return nil, time.Time{}, errNotStarted
+	}
+
+	var cache any
+	var refreshed time.Time
+	var err error
+	if client != nil {
+		cache, err = client.GetFlagsFromCache()
+		refreshed = client.GetCacheRefreshDate()
+	} else {
+		cache, err = ffclient.GetFlagsFromCache()
+		refreshed = ffclient.GetCacheRefreshDate()
+	}
+	if err != nil {
+		return nil, refreshed, fmt.Errorf("failed to get flags from cache: %w", err)
+	}
+
+	// goff's flags are internal types, but they marshal to the flag file format
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return nil, refreshed, fmt.Errorf("failed to marshal flags: %w", err)
+	}
+	f, err := parseFlagFile(b, "json")
+	return f, refreshed, err
+}
+
+var publishOnce sync.Once
+
+func publishExpvar() {
+	publishOnce.Do(func() {
+		expvar.Publish("flags", expvar.Func(func() any {
+			f, refreshed, err := cachedFlags(nil)
+			if err != nil {
+				return map[string]any{"error": err.Error()}
+			}
+			return map[string]any{
+				"count":     len(f),
+				"refreshed": refreshed,
+				"flags":     slices.Sorted(maps.Keys(f)),
+			}
+		}))
+	})
+}
+
+type debugFlag struct {
+	Name       string
+	Variations string
+	Targeting  string
+	Default    string
+	Metadata   string
+}
+
+type debugEvaluation struct {
+	Flag    string
+	UserID  string
+	Attrs   string
+	Value   string
+	Reason  string
+	Variant string
+	Error   string
+}
+
+type debugPage struct {
+	Refreshed  time.Time
+	Flags      []debugFlag
+	Evaluation *debugEvaluation
+	Error      string
+}
+
+// DebugHandler serves a page listing every loaded flag with its variations,
+// targeting and metadata, and a form to evaluate a flag for any subject.
+// It also publishes the flag cache as the "flags" expvar. Add ?format=json
+// to get the flags as JSON.
+func DebugHandler(client ...*ffclient.GoFeatureFlag) http.Handler {
+	publishExpvar()
+	var c *ffclient.GoFeatureFlag
+	if len(client) > 0 {
+		c = client[0]
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, refreshed, err := cachedFlags(c)
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			if err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"refreshed": refreshed, "flags": f})
+			return
+		}
+
+		page := debugPage{Refreshed: refreshed}
+		if err != nil {
+			page.Error = err.Error()
+		}
+		for _, name := range slices.Sorted(maps.Keys(f)) {
+			def := f[name]
+			page.Flags = append(page.Flags, debugFlag{
+				Name:       name,
+				Variations: indent(def["variations"]),
+				Targeting:  indent(def["targeting"]),
+				Default:    indent(def["defaultRule"]),
+				Metadata:   indent(def["metadata"]),
+			})
+		}
+		if flag := r.URL.Query().Get("flag"); flag != "" {
+			var e Evaluator = c
+			if c == nil {
+				e = Default()
+			}
+			page.Evaluation = debugEvaluate(e, f, flag, r.URL.Query().Get("user"), r.URL.Query().Get("attrs"))
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err = debugTemplate.Execute(w, page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// debugEvaluate evaluates the flag through the getter matching the type of
+// its variations, attrs are comma separated key=value custom attributes.
+func debugEvaluate(e Evaluator, f flagFile, flag, userID, attrs string) *debugEvaluation {
+	out := &debugEvaluation{Flag: flag, UserID: userID, Attrs: attrs}
+	if userID == "" {
+		userID = "anonymous"
+	}
+	b := ffcontext.NewEvaluationContextBuilder(userID)
+	for _, kv := range strings.Split(attrs, ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(kv), "="); ok {
+			b.AddCustom(k, v)
+		}
+	}
+	c := b.Build()
+
+	kind := "string"
+	if variations, ok := f.variations(flag); ok {
+		for _, v := range variations {
+			kind = variationKind(v)
+			break
+		}
+	}
+
+	var value any
+	var variant, reason string
+	var err error
+	switch kind {
+	case "bool":
+		res, evalErr := e.BoolVariationDetails(flag, c, false)
+		value, variant, reason, err = res.Value, res.VariationType, res.Reason, evalErr
+	case "number":
+		// the cache doesn't tell ints and floats apart, so try int first
+		res, evalErr := e.IntVariationDetails(flag, c, 0)
+		value, variant, reason, err = res.Value, res.VariationType, res.Reason, evalErr
+		if res.ErrorCode == errorCodeTypeMismatch {
+			res, evalErr := e.Float64VariationDetails(flag, c, 0)
+			value, variant, reason, err = res.Value, res.VariationType, res.Reason, evalErr
+		}
+	case "object":
+		res, evalErr := e.JSONVariationDetails(flag, c, nil)
+		value, variant, reason, err = res.Value, res.VariationType, res.Reason, evalErr
+	case "array":
+		res, evalErr := e.JSONArrayVariationDetails(flag, c, nil)
+		value, variant, reason, err = res.Value, res.VariationType, res.Reason, evalErr
+	default:
+		res, evalErr := e.StringVariationDetails(flag, c, "")
+		value, variant, reason, err = res.Value, res.VariationType, res.Reason, evalErr
+	}
+	out.Value, out.Variant, out.Reason = indent(value), variant, reason
+	if err != nil {
+		out.Error = err.Error()
+	}
+	return out
+}
+
+func indent(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+var debugTemplate = template.Must(template.New("flags").Parse(`<!DOCTYPE html>
+<html>
+<head><title>/debug/flags</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { margin: 0; }
+</style>
+</head>
+<body>
+<h1>/debug/flags</h1>
+{{if .Error}}<p><b>error:</b> {{.Error}}</p>{{end}}
+<p>last refresh: {{if .Refreshed.IsZero}}never{{else}}{{.Refreshed.Format "2006-01-02T15:04:05Z07:00"}}{{end}}</p>
+
+<h2>Evaluate</h2>
+<form method="get">
+flag <input name="flag" value="{{with .Evaluation}}{{.Flag}}{{end}}">
+user <input name="user" value="{{with .Evaluation}}{{.UserID}}{{end}}">
+attributes <input name="attrs" placeholder="key=value,key=value" value="{{with .Evaluation}}{{.Attrs}}{{end}}">
+<input type="submit" value="evaluate">
+</form>
+{{with .Evaluation}}
+<table>
+<tr><th>value</th><td><pre>{{.Value}}</pre></td></tr>
+<tr><th>variant</th><td>{{.Variant}}</td></tr>
+<tr><th>reason</th><td>{{.Reason}}</td></tr>
+{{if .Error}}<tr><th>error</th><td>{{.Error}}</td></tr>{{end}}
+</table>
+{{end}}
+
+<h2>Flags ({{len .Flags}})</h2>
+<table>
+<tr><th>flag</th><th>variations</th><th>targeting</th><th>default rule</th><th>metadata</th></tr>
+{{range .Flags}}
+<tr>
+<td><a href="?flag={{.Name}}">{{.Name}}</a></td>
+<td><pre>{{.Variations}}</pre></td>
+<td><pre>{{.Targeting}}</pre></td>
+<td><pre>{{.Default}}</pre></td>
+<td><pre>{{.Metadata}}</pre></td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
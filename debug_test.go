@@ -0,0 +1,65 @@
+package flags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	c := setupClient(t, yamlFlagFileName)
+	defer c.Close()
+	h := DebugHandler(c)
+
+	t.Run("json lists every flag", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/flags?format=json", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: got %d want %d", rec.Code, http.StatusOK)
+		}
+		var resp struct {
+			Flags map[string]map[string]any `json:"flags"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("unexpected error decoding response: %v", err)
+		}
+		if len(resp.Flags) != 9 {
+			t.Errorf("unexpected flags: got %d want %d", len(resp.Flags), 9)
+		}
+		if _, ok := resp.Flags[enabledByIDFlagName]["targeting"]; !ok {
+			t.Errorf("expected targeting rules for %s", enabledByIDFlagName)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		contains []string
+	}{
+		{"page lists flags", "", []string{enabledByIDFlagName, "Enable feature X by Y ID", "last refresh: 20"}},
+		{"evaluates for subject with attributes", "?flag=is-enabled-for-user&user=x&attrs=user-id%3D2", []string{"<pre>true</pre>", "TARGETING_MATCH"}},
+		{"evaluates number flag", "?flag=ff-number", []string{"<pre>9081</pre>"}},
+		{"evaluates missing flag", "?flag=not-exists", []string{"is not present or disabled"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/flags"+tt.query, nil))
+			for _, want := range tt.contains {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("expected page to contain %s", want)
+				}
+			}
+		})
+	}
+}
+
+func TestDebugHandlerNotStarted(t *testing.T) {
+	rec := httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/flags?format=json", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status: got %d want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
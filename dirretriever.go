@@ -0,0 +1,138 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var defaultDirInclude = []string{"*.goff.yaml", "*.goff.yml", "*.goff.json", "*.goff.toml"}
+
+// DirRetriever merges every flag file in a directory into one flag set, so
+// teams can own their own files. A flag defined in more than one file is an
+// error naming both.
+type DirRetriever struct {
+	Dir       string
+	Recursive bool
+	// Include and Exclude are path.Match patterns, matched against the file
+	// name, or the slash separated path relative to Dir if they contain a
+	// '/'. Default include: *.goff.yaml, *.goff.yml, *.goff.json, *.goff.toml
+	Include []string
+	Exclude []string
+	// Format the merged flag set is returned in, it has to match
+	// Config.FileFormat. Default: yaml
+	Format string
+
+	mu      sync.Mutex
+	sources map[string]string
+}
+
+func (r *DirRetriever) Retrieve(ctx context.Context) ([]byte, error) {
+	merged := flagFile{}
+	sources := map[string]string{}
+	err := filepath.WalkDir(r.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, err := filepath.Rel(r.Dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && !r.Recursive {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !r.included(rel) {
+			return nil
+		}
+		f, err := readFlagFile(p)
+		if err != nil {
+			return err
+		}
+		for k, v := range f {
+			if first, ok := sources[k]; ok {
+				return fmt.Errorf("flag %s is defined in both %s and %s", k, first, p)
+			}
+			sources[k] = p
+			merged[k] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load flag directory %s: %w", r.Dir, err)
+	}
+
+	r.mu.Lock()
+	r.sources = sources
+	r.mu.Unlock()
+	return marshalFlagFile(merged, r.format())
+}
+
+// Sources maps each flag of the last retrieval to the file defining it.
+func (r *DirRetriever) Sources() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.sources)
+}
+
+func (r *DirRetriever) format() string {
+	if r.Format == "" {
+		return "yaml"
+	}
+	return r.Format
+}
+
+func (r *DirRetriever) included(rel string) bool {
+	include := r.Include
+	if len(include) == 0 {
+		include = defaultDirInclude
+	}
+	return matchAny(include, rel) && !matchAny(r.Exclude, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		name := path.Base(rel)
+		if strings.Contains(p, "/") {
+			name = rel
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fileFormatOf is the flag file format given by the extension of p.
+func fileFormatOf(p string) string {
+	switch ext := strings.TrimPrefix(filepath.Ext(p), "."); ext {
+	case "json", "toml":
+		return ext
+	}
+	return "yaml"
+}
+
+// readFlagFile parses a flag file in the format given by its extension.
+func readFlagFile(p string) (flagFile, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseFlagFile(b, fileFormatOf(p))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,125 @@
+package flags
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+func writeFlagDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		writeFile(t, p, content)
+	}
+	return dir
+}
+
+func TestDirRetriever(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"payments.goff.yaml":      "pay-enabled:\n  variations:\n    on: true\n  defaultRule:\n    variation: on\n",
+		"search.goff.json":        `{"search-limit": {"variations": {"n": 10}, "defaultRule": {"variation": "n"}}}`,
+		"growth/growth.goff.toml": "[growth-banner.variations]\non = true\n[growth-banner.defaultRule]\nvariation = \"on\"\n",
+		"notes.yaml":              "not: [a, flag, file]",
+	}
+	tests := []struct {
+		name      string
+		r         *DirRetriever
+		want      []string
+		expectErr string
+	}{
+		{
+			name: "top level",
+			r:    &DirRetriever{},
+			want: []string{"pay-enabled", "search-limit"},
+		},
+		{
+			name: "recursive",
+			r:    &DirRetriever{Recursive: true},
+			want: []string{"growth-banner", "pay-enabled", "search-limit"},
+		},
+		{
+			name: "include and exclude",
+			r:    &DirRetriever{Recursive: true, Include: []string{"growth/*"}, Exclude: []string{"*.json"}},
+			want: []string{"growth-banner"},
+		},
+		{
+			name: "json output",
+			r:    &DirRetriever{Format: "json"},
+			want: []string{"pay-enabled", "search-limit"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.r.Dir = writeFlagDir(t, files)
+			b, err := tt.r.Retrieve(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			f, err := parseFlagFile(b, tt.r.format())
+			if err != nil {
+				t.Fatalf("unexpected error parsing the merged flags: %v", err)
+			}
+			got := slices.Sorted(maps.Keys(f))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected flags (-want +got):\n%s", diff)
+			}
+			sources := tt.r.Sources()
+			for _, k := range tt.want {
+				if !strings.HasPrefix(sources[k], tt.r.Dir) {
+					t.Errorf("expected a source file for %s, got %q", k, sources[k])
+				}
+			}
+		})
+	}
+}
+
+func TestDirRetrieverDuplicate(t *testing.T) {
+	t.Parallel()
+	dir := writeFlagDir(t, map[string]string{
+		"a.goff.yaml": "dup:\n  variations:\n    on: true\n",
+		"b.goff.yaml": "dup:\n  variations:\n    on: false\n",
+	})
+	_, err := (&DirRetriever{Dir: dir}).Retrieve(context.Background())
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+	for _, f := range []string{"a.goff.yaml", "b.goff.yaml"} {
+		if !strings.Contains(err.Error(), f) {
+			t.Errorf("expected the error to name %s, got %v", f, err)
+		}
+	}
+}
+
+func TestNewClientDirRetriever(t *testing.T) {
+	dir := writeFlagDir(t, map[string]string{
+		"a.goff.yaml": "ff-number:\n  variations:\n    n: 3\n  defaultRule:\n    variation: n\n",
+	})
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&DirRetriever{Dir: dir}},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 3 {
+		t.Errorf("expected 3, got %d, %v", got, err)
+	}
+}
@@ -0,0 +1,134 @@
+package flags
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+const envOverridePrefix = "FLAGS_OVERRIDE_"
+
+// EnvOverrides serves flags set through FLAGS_OVERRIDE_<NAME> environment
+// variables, where NAME is the flag key upper cased with anything that isn't
+// a letter or digit replaced by an underscore, e.g. is-enabled becomes
+// FLAGS_OVERRIDE_IS_ENABLED. The environment is read once on creation.
+type EnvOverrides struct {
+	values map[string]string
+}
+
+func NewEnvOverrides() *EnvOverrides {
+	return newEnvOverrides(os.Environ())
+}
+
+func newEnvOverrides(environ []string) *EnvOverrides {
+	values := map[string]string{}
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(k, envOverridePrefix) {
+			continue
+		}
+		values[k] = v
+	}
+	return &EnvOverrides{values: values}
+}
+
+func (e *EnvOverrides) Len() int {
+	return len(e.values)
+}
+
+func (e *EnvOverrides) log(logger *slog.Logger) {
+	for k, v := range e.values {
+		logger.Info("flag override applied from environment", "env", k, "value", v)
+	}
+}
+
+func envName(flag string) string {
+	return envOverridePrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, flag)
+}
+
+func (e *EnvOverrides) lookup(flag string) (string, bool) {
+	v, ok := e.values[envName(flag)]
+	return v, ok
+}
+
+func (e *EnvOverrides) BoolVariationDetails(flag string, _ ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	raw, ok := e.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, strconv.ParseBool)
+}
+
+func (e *EnvOverrides) IntVariationDetails(flag string, _ ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	raw, ok := e.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, strconv.Atoi)
+}
+
+func (e *EnvOverrides) Float64VariationDetails(flag string, _ ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	raw, ok := e.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseFloat)
+}
+
+func (e *EnvOverrides) StringVariationDetails(flag string, _ ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	raw, ok := e.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseString)
+}
+
+func (e *EnvOverrides) JSONVariationDetails(flag string, _ ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	raw, ok := e.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseJSON[map[string]any])
+}
+
+func (e *EnvOverrides) JSONArrayVariationDetails(flag string, _ ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	raw, ok := e.lookup(flag)
+	return parsedVariation(raw, ok, flag, defaultValue, parseJSON[[]any])
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+func parseJSON[T any](s string) (T, error) {
+	var v T
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+// parsedVariation serves overrides that are given as strings, parsing them
+// for the getter they are read through.
+func parsedVariation[T model.JSONType](
+	raw string,
+	ok bool,
+	flag string,
+	defaultValue T,
+	parse func(string) (T, error),
+) (model.VariationResult[T], error) {
+	if !ok {
+		return defaultResult(defaultValue, errorCodeFlagNotFound), fmt.Errorf("flag %v is not present or disabled", flag)
+	}
+	v, err := parse(raw)
+	if err != nil {
+		return defaultResult(defaultValue, errorCodeTypeMismatch), fmt.Errorf("failed to parse override %q for flag %s: %w", raw, flag, err)
+	}
+	return model.VariationResult[T]{
+		Value:         v,
+		VariationType: variationStatic,
+		Reason:        reasonStatic,
+		Cacheable:     true,
+	}, nil
+}
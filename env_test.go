@@ -0,0 +1,100 @@
+package flags
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	e := newEnvOverrides([]string{
+		"FLAGS_OVERRIDE_IS_ENABLED=false",
+		"FLAGS_OVERRIDE_FF_NUMBER=42",
+		"FLAGS_OVERRIDE_FF_FLOAT=2.71828",
+		"FLAGS_OVERRIDE_FF_DESCRIPTION=Boo!",
+		"FLAGS_OVERRIDE_CR_START=2030-01-02T03:04:05Z",
+		`FLAGS_OVERRIDE_FF_JSON={"p50":10}`,
+		"FLAGS_OVERRIDE_FF_JSON_LIST=[4,5]",
+		"PATH=/usr/bin",
+	})
+	if e.Len() != 7 {
+		t.Errorf("unexpected overrides: got %d want %d", e.Len(), 7)
+	}
+	l := NewLayered(setupClient(t, yamlFlagFileName), e)
+
+	b, err := IsEnabled(isEnabledFlagName, "1", true, l)
+	if err != nil || b {
+		t.Errorf("unexpected bool: got %t, %v want %t", b, err, false)
+	}
+	i, err := GetInt(numberFlagName, "1", 69, l)
+	if err != nil || i != 42 {
+		t.Errorf("unexpected int: got %d, %v want %d", i, err, 42)
+	}
+	f, err := GetFloat(floatFlagName, "1", 1.11, l)
+	if err != nil || f != 2.71828 {
+		t.Errorf("unexpected float: got %f, %v want %f", f, err, 2.71828)
+	}
+	s, err := GetString(descriptionFlagName, "1", "hello", l)
+	if err != nil || s != "Boo!" {
+		t.Errorf("unexpected string: got %s, %v want %s", s, err, "Boo!")
+	}
+	ti, err := GetTime(timeFlagName, "1", time.RFC3339, time.Time{}, l)
+	if err != nil || ti.Year() != 2030 {
+		t.Errorf("unexpected time: got %s, %v", ti, err)
+	}
+	j, err := GetJSONMap(jsonFlagName, "1", nil, l)
+	if err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if diff := cmp.Diff(j, map[string]any{"p50": 10.}); diff != "" {
+		t.Errorf("unexpected struct (-got +want)\n%s", diff)
+	}
+	b, err = IsEnabledByIDList(idListIntFlag, "1", 1, true, l)
+	if err != nil || b {
+		t.Errorf("unexpected bool: got %t, %v want %t", b, err, false)
+	}
+
+	t.Run("unparseable override returns default and error", func(t *testing.T) {
+		e := newEnvOverrides([]string{"FLAGS_OVERRIDE_FF_NUMBER=lots"})
+		i, err := GetInt(numberFlagName, "1", 69, e)
+		if err == nil {
+			t.Errorf("expected error but got nil")
+		}
+		if i != 69 {
+			t.Errorf("unexpected int: got %d want %d", i, 69)
+		}
+	})
+}
+
+func TestNewClientEnvOverrides(t *testing.T) {
+	t.Setenv("FLAGS_OVERRIDE_FF_NUMBER", "42")
+	tests := []struct {
+		name     string
+		disable  bool
+		expected int
+	}{
+		{"env overrides applied", false, 42},
+		{"env overrides disabled", true, 9081},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewClient(Config{
+				Evaluator:           NewInMemory(map[string]any{numberFlagName: 9081}),
+				DisableEnvOverrides: tt.disable,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating client: %v", err)
+			}
+			defer Close()
+
+			i, err := GetInt(numberFlagName, "1", 69)
+			if err != nil {
+				t.Fatalf("unexpected error getting flag value: %v", err)
+			}
+			if i != tt.expected {
+				t.Errorf("unexpected int: got %d want %d", i, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package flags
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+// Evaluator is what the getters evaluate flags against,
+// *ffclient.GoFeatureFlag satisfies it out of the box.
+type Evaluator interface {
+	BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error)
+	IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error)
+	Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error)
+	StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error)
+	JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error)
+	JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error)
+}
+
+var (
+	defaultMu        sync.RWMutex
+	defaultEvaluator Evaluator = singleton{}
+	hooks            []Hook
+	// goffStarted is set while the ffclient singleton is initialised,
+	// its package level functions panic before that
+	goffStarted bool
+	current     = newStartup()
+)
+
+// install makes base, wrapped in the configured overrides, the default
+// evaluator and applies the rest of the package level config. It reports
+// false, leaving everything as is, if s is no longer the current startup,
+// i.e. Close or another NewClient was called since.
+func install(cfg Config, base Evaluator, s *startup) bool {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if current != s {
+		return false
+	}
+	defaultEvaluator = withOverrides(base, cfg.Overrides)
+	goffStarted = isGoff(base)
+	hooks = hooksFor(cfg)
+	return true
+}
+
+// unwrapper is implemented by evaluators that decorate another one.
+type unwrapper interface {
+	unwrap() Evaluator
+}
+
+func isGoff(e Evaluator) bool {
+	for {
+		switch v := e.(type) {
+		case singleton:
+			return true
+		case unwrapper:
+			e = v.unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+func currentHooks() []Hook {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return hooks
+}
+
+func errorKind(code string) string {
+	if code == "" {
+		return "general"
+	}
+	return strings.ToLower(code)
+}
+
+func Default() Evaluator {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultEvaluator
+}
+
+func evaluatorFor(client []Evaluator) Evaluator {
+	if len(client) > 0 && client[0] != nil {
+		// a typed nil goff client falls back to the default, as it did
+		// before the getters took an Evaluator
+		if g, ok := client[0].(*ffclient.GoFeatureFlag); !ok || g != nil {
+			return client[0]
+		}
+	}
+	return Default()
+}
+
+// evaluate is the single path every getter goes through, running the
+// configured hooks around the evaluation.
+func evaluate[T model.JSONType](
+	ctx context.Context,
+	flag string,
+	c ffcontext.Context,
+	defaultValue T,
+	eval func(string, ffcontext.Context, T) (model.VariationResult[T], error),
+) (T, error) {
+	res, err := evaluateDetails(ctx, flag, c, defaultValue, eval)
+	return res.Value, err
+}
+
+// evaluateDetails is evaluate returning the whole result, an error is
+// always an *EvaluationError.
+func evaluateDetails[T model.JSONType](
+	ctx context.Context,
+	flag string,
+	c ffcontext.Context,
+	defaultValue T,
+	eval func(string, ffcontext.Context, T) (model.VariationResult[T], error),
+) (model.VariationResult[T], error) {
+	hs := currentHooks()
+	hc := HookContext{Flag: flag, Subject: c, Default: defaultValue}
+	start := time.Now()
+
+	var res model.VariationResult[T]
+	var err error
+	for _, h := range hs {
+		if err = h.Before(ctx, &hc); err != nil {
+			res = defaultResult(defaultValue, errorCodeGeneral)
+			break
+		}
+	}
+	if err == nil {
+		res, err = eval(flag, hc.Subject, defaultValue)
+	}
+
+	details := EvaluationDetails{
+		Flag:      flag,
+		Value:     res.Value,
+		Variation: res.VariationType,
+		Reason:    res.Reason,
+		ErrorCode: res.ErrorCode,
+		Metadata:  res.Metadata,
+		Latency:   time.Since(start),
+	}
+	if err != nil {
+		details.Err = &EvaluationError{Flag: flag, Code: res.ErrorCode, Err: err}
+		for _, h := range hs {
+			h.Error(ctx, hc, details.Err)
+		}
+	} else {
+		for _, h := range hs {
+			h.After(ctx, hc, details)
+		}
+	}
+	for _, h := range hs {
+		h.Finally(ctx, hc, details)
+	}
+	if details.Err != nil {
+		return res, details.Err
+	}
+	return res, nil
+}
+
+// WithHooks runs the configured hooks around every evaluation of e, the same
+// as the getters, for callers that need the details of the evaluation.
+// Errors are *EvaluationError.
+func WithHooks(e Evaluator) Evaluator {
+	return hooked{Evaluator: e}
+}
+
+type hooked struct {
+	Evaluator
+}
+
+func (h hooked) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return evaluateDetails(context.Background(), flag, ctx, defaultValue, h.Evaluator.BoolVariationDetails)
+}
+
+func (h hooked) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return evaluateDetails(context.Background(), flag, ctx, defaultValue, h.Evaluator.IntVariationDetails)
+}
+
+func (h hooked) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return evaluateDetails(context.Background(), flag, ctx, defaultValue, h.Evaluator.Float64VariationDetails)
+}
+
+func (h hooked) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return evaluateDetails(context.Background(), flag, ctx, defaultValue, h.Evaluator.StringVariationDetails)
+}
+
+func (h hooked) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return evaluateDetails(context.Background(), flag, ctx, defaultValue, h.Evaluator.JSONVariationDetails)
+}
+
+func (h hooked) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return evaluateDetails(context.Background(), flag, ctx, defaultValue, h.Evaluator.JSONArrayVariationDetails)
+}
+
+// singleton evaluates against the ffclient package level instance set up by NewClient.
+type singleton struct{}
+
+func (singleton) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return ffclient.BoolVariationDetails(flag, ctx, defaultValue)
+}
+
+func (singleton) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return ffclient.IntVariationDetails(flag, ctx, defaultValue)
+}
+
+func (singleton) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return ffclient.Float64VariationDetails(flag, ctx, defaultValue)
+}
+
+func (singleton) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return ffclient.StringVariationDetails(flag, ctx, defaultValue)
+}
+
+func (singleton) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return ffclient.JSONVariationDetails(flag, ctx, defaultValue)
+}
+
+func (singleton) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return ffclient.JSONArrayVariationDetails(flag, ctx, defaultValue)
+}
+
+func (singleton) ForceRefresh() bool {
+	return ffclient.ForceRefresh()
+}
+
+func (singleton) Close() {
+	ffclient.Close()
+}
@@ -0,0 +1,104 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInMemory(t *testing.T) {
+	m := NewInMemory(map[string]any{
+		isEnabledFlagName:   true,
+		numberFlagName:      9081.,
+		floatFlagName:       3,
+		descriptionFlagName: "Boo!",
+		jsonFlagName:        map[string]any{"p50": 40},
+		idListIntFlag:       []any{1, 2, 3},
+	})
+
+	b, err := IsEnabled(isEnabledFlagName, "1", false, m)
+	if err != nil || !b {
+		t.Errorf("unexpected bool: got %t, %v want %t", b, err, true)
+	}
+	i, err := GetInt(numberFlagName, "1", 69, m)
+	if err != nil || i != 9081 {
+		t.Errorf("unexpected int: got %d, %v want %d", i, err, 9081)
+	}
+	f, err := GetFloat(floatFlagName, "1", 1.11, m)
+	if err != nil || f != 3 {
+		t.Errorf("unexpected float: got %f, %v want %f", f, err, 3.)
+	}
+	s, err := GetString(descriptionFlagName, "1", "hello", m)
+	if err != nil || s != "Boo!" {
+		t.Errorf("unexpected string: got %s, %v want %s", s, err, "Boo!")
+	}
+	j, err := GetJSONMap(jsonFlagName, "1", nil, m)
+	if err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if diff := cmp.Diff(j, map[string]any{"p50": 40}); diff != "" {
+		t.Errorf("unexpected struct (-got +want)\n%s", diff)
+	}
+	b, err = IsEnabledByIDList(idListIntFlag, "1", 2, false, m)
+	if err != nil || !b {
+		t.Errorf("unexpected bool: got %t, %v want %t", b, err, true)
+	}
+
+	t.Run("flag doesnt exist, returns default", func(t *testing.T) {
+		i, err := GetInt(notExistsFlagName, "1", 69, m)
+		if err == nil {
+			t.Errorf("expected error but got nil")
+		}
+		if i != 69 {
+			t.Errorf("unexpected int: got %d want %d", i, 69)
+		}
+	})
+	t.Run("wrong type, returns default", func(t *testing.T) {
+		i, err := GetInt(descriptionFlagName, "1", 69, m)
+		if err == nil {
+			t.Errorf("expected error but got nil")
+		}
+		if i != 69 {
+			t.Errorf("unexpected int: got %d want %d", i, 69)
+		}
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	c := setupClient(t, yamlFlagFileName)
+	r := NewRecorder(c)
+
+	if _, err := GetInt(numberFlagName, "1", 69, r); err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if _, err := IsEnabled(notExistsFlagName, "", false, r); err == nil {
+		t.Fatalf("expected error but got nil")
+	}
+
+	got := r.Evaluations()
+	if len(got) != 2 {
+		t.Fatalf("unexpected evaluations: got %d want %d", len(got), 2)
+	}
+	if got[0].Flag != numberFlagName || got[0].Subject != "1" || got[0].Value != 9081 {
+		t.Errorf("unexpected evaluation: %+v", got[0])
+	}
+	if got[1].Subject != "anonymous" || got[1].Err == nil || got[1].Value != false {
+		t.Errorf("unexpected evaluation: %+v", got[1])
+	}
+}
+
+func TestNewClientWithEvaluator(t *testing.T) {
+	m := NewInMemory(map[string]any{numberFlagName: 42})
+	if err := NewClient(Config{Evaluator: m}); err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	i, err := GetInt(numberFlagName, "1", 69)
+	if err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if i != 42 {
+		t.Errorf("unexpected int: got %d want %d", i, 42)
+	}
+}
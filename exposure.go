@@ -0,0 +1,326 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Exposure records that a subject was served a variation of a flag.
+type Exposure struct {
+	Flag      string    `json:"flag"`
+	Subject   string    `json:"subject"`
+	Variation string    `json:"variation"`
+	Reason    string    `json:"reason"`
+	Value     any       `json:"value"`
+	Revision  string    `json:"revision,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// ExposureSink receives batches of exposures from an ExposureLogger.
+type ExposureSink interface {
+	Write(ctx context.Context, exposures []Exposure) error
+}
+
+type ExposureConfig struct {
+	Sink ExposureSink
+	// Window within which a subject seeing the same variation of a flag
+	// again isn't logged. Default: 1h
+	Window time.Duration
+	// BatchSize flushes once this many exposures are pending. Default: 100
+	BatchSize int
+	// FlushInterval flushes pending exposures periodically. Default: 10s
+	FlushInterval time.Duration
+	// FlushTimeout bounds each background flush and the one on Close.
+	// Default: 10s
+	FlushTimeout time.Duration
+	// MaxPending caps the exposures kept for the next flush while the sink
+	// is failing, the oldest are dropped first. Default: 10000
+	MaxPending int
+	// Logger reports sink failures. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// ExposureLogger is a Hook that logs an Exposure for every successful
+// evaluation. It flushes when closed, which flags.Close does for hooks
+// passed in Config.Hooks.
+type ExposureLogger struct {
+	BaseHook
+	sink       ExposureSink
+	window     time.Duration
+	batchSize  int
+	maxPending int
+	timeout    time.Duration
+	logger     *slog.Logger
+	now        func() time.Time
+
+	mu      sync.Mutex
+	seen    map[exposureKey]time.Time
+	pending []Exposure
+
+	flushMu sync.Mutex
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+type exposureKey struct {
+	flag, subject, variation string
+}
+
+func NewExposureLogger(cfg ExposureConfig) (*ExposureLogger, error) {
+	if cfg.Sink == nil {
+		return nil, errors.New("exposure logger expects a sink")
+	}
+	window := time.Hour
+	if cfg.Window > 0 {
+		window = cfg.Window
+	}
+	batchSize := 100
+	if cfg.BatchSize > 0 {
+		batchSize = cfg.BatchSize
+	}
+	interval := 10 * time.Second
+	if cfg.FlushInterval > 0 {
+		interval = cfg.FlushInterval
+	}
+	timeout := 10 * time.Second
+	if cfg.FlushTimeout > 0 {
+		timeout = cfg.FlushTimeout
+	}
+	maxPending := 10000
+	if cfg.MaxPending > 0 {
+		maxPending = cfg.MaxPending
+	}
+	logger := slog.Default()
+	if cfg.Logger != nil {
+		logger = cfg.Logger
+	}
+	l := &ExposureLogger{
+		sink:       cfg.Sink,
+		window:     window,
+		batchSize:  batchSize,
+		maxPending: maxPending,
+		timeout:    timeout,
+		logger:     logger,
+		now:        time.Now,
+		seen:       map[exposureKey]time.Time{},
+		full:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	go l.run(interval)
+	return l, nil
+}
+
+func (l *ExposureLogger) After(_ context.Context, hc HookContext, d EvaluationDetails) {
+	l.Log(Exposure{
+		Flag:      hc.Flag,
+		Subject:   hc.Subject.GetKey(),
+		Variation: d.Variation,
+		Reason:    d.Reason,
+		Value:     d.Value,
+		Revision:  revisionOf(d.Metadata),
+	})
+}
+
+// Log queues an exposure unless the same one was logged within the window.
+func (l *ExposureLogger) Log(e Exposure) {
+	now := l.now()
+	if e.Time.IsZero() {
+		e.Time = now
+	}
+	k := exposureKey{flag: e.Flag, subject: e.Subject, variation: e.Variation}
+
+	l.mu.Lock()
+	if last, ok := l.seen[k]; ok && now.Sub(last) < l.window {
+		l.mu.Unlock()
+		return
+	}
+	l.seen[k] = now
+	l.pending = append(l.pending, e)
+	l.trim()
+	// on every batch size worth, not every exposure, so a failing sink
+	// isn't retried on each evaluation
+	full := len(l.pending)%l.batchSize == 0
+	l.mu.Unlock()
+
+	if full {
+		select {
+		case l.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// trim drops the oldest pending exposures over MaxPending, l.mu must be held.
+func (l *ExposureLogger) trim() {
+	if n := len(l.pending) - l.maxPending; n > 0 {
+		l.pending = slices.Delete(l.pending, 0, n)
+		l.logger.Error("too many pending exposures, dropping the oldest", "dropped", n)
+	}
+}
+
+// Flush writes the pending exposures to the sink. If that fails they are
+// kept for the next flush.
+func (l *ExposureLogger) Flush(ctx context.Context) error {
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	now := l.now()
+	for k, last := range l.seen {
+		if now.Sub(last) >= l.window {
+			delete(l.seen, k)
+		}
+	}
+	l.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := l.sink.Write(ctx, batch); err != nil {
+		l.mu.Lock()
+		l.pending = append(batch, l.pending...)
+		l.trim()
+		l.mu.Unlock()
+		return fmt.Errorf("failed to write %d exposures: %w", len(batch), err)
+	}
+	return nil
+}
+
+// Close stops the background flushing, flushes what is pending within
+// FlushTimeout and closes the sink if it has a Close method.
+func (l *ExposureLogger) Close() {
+	l.once.Do(func() {
+		close(l.done)
+		<-l.stopped
+		l.flush()
+		if c, ok := l.sink.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				l.logger.Error("failed to close exposure sink", "error", err)
+			}
+		}
+	})
+}
+
+func (l *ExposureLogger) run(interval time.Duration) {
+	defer close(l.stopped)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-t.C:
+			l.flush()
+		case <-l.full:
+			l.flush()
+		}
+	}
+}
+
+func (l *ExposureLogger) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	if err := l.Flush(ctx); err != nil {
+		l.logger.Error("failed to flush exposures", "error", err)
+	}
+}
+
+// WriterSink writes exposures as JSON lines.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Write(_ context.Context, exposures []Exposure) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range exposures {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to encode exposure: %w", err)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write exposures: %w", err)
+	}
+	return nil
+}
+
+// FileSink appends exposures to a JSON lines file.
+type FileSink struct {
+	*WriterSink
+	f *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exposure file: %w", err)
+	}
+	return &FileSink{WriterSink: NewWriterSink(f), f: f}, nil
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+// HTTPSink POSTs each batch of exposures as a JSON array.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink posts to url with client, a client with a 10s timeout if nil.
+func NewHTTPSink(url string, client *http.Client) *HTTPSink {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPSink{url: url, client: client}
+}
+
+func (s *HTTPSink) Write(ctx context.Context, exposures []Exposure) error {
+	b, err := json.Marshal(exposures)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exposures: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create exposure request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send exposures: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send exposures: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func revisionOf(metadata map[string]any) string {
+	rev, _ := metadata[MetadataRevision].(string)
+	return rev
+}
@@ -0,0 +1,264 @@
+package flags
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	batches [][]Exposure
+	written chan struct{}
+}
+
+func newMemorySink() *memorySink {
+	return &memorySink{written: make(chan struct{}, 10)}
+}
+
+func (s *memorySink) Write(_ context.Context, exposures []Exposure) error {
+	s.mu.Lock()
+	s.batches = append(s.batches, exposures)
+	s.mu.Unlock()
+	s.written <- struct{}{}
+	return nil
+}
+
+func (s *memorySink) exposures() []Exposure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []Exposure
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func TestExposureLoggerDedup(t *testing.T) {
+	t.Parallel()
+	sink := newMemorySink()
+	l, err := NewExposureLogger(ExposureConfig{Sink: sink, Window: time.Minute, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	l.Log(Exposure{Flag: "a", Subject: "u1", Variation: "on"})
+	l.Log(Exposure{Flag: "a", Subject: "u1", Variation: "on"})
+	l.Log(Exposure{Flag: "a", Subject: "u1", Variation: "off"})
+	l.Log(Exposure{Flag: "a", Subject: "u2", Variation: "on"})
+	now = now.Add(time.Minute)
+	l.Log(Exposure{Flag: "a", Subject: "u1", Variation: "on"})
+	l.Close()
+
+	if got := len(sink.exposures()); got != 4 {
+		t.Errorf("expected 4 exposures, got %d: %+v", got, sink.exposures())
+	}
+}
+
+func TestExposureLoggerBatchSize(t *testing.T) {
+	t.Parallel()
+	sink := newMemorySink()
+	l, err := NewExposureLogger(ExposureConfig{Sink: sink, BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l.Close()
+
+	l.Log(Exposure{Flag: "a", Subject: "u1"})
+	l.Log(Exposure{Flag: "a", Subject: "u2"})
+	select {
+	case <-sink.written:
+	case <-time.After(time.Second):
+		t.Fatal("expected a full batch to be flushed")
+	}
+	if got := len(sink.exposures()); got != 2 {
+		t.Errorf("expected 2 exposures, got %d", got)
+	}
+}
+
+// flakySink fails until healed and blocks while hang is set.
+type flakySink struct {
+	*memorySink
+	mu     sync.Mutex
+	failed int
+	healed bool
+	hang   bool
+}
+
+func (s *flakySink) Write(ctx context.Context, exposures []Exposure) error {
+	s.mu.Lock()
+	healed, hang := s.healed, s.hang
+	if !healed {
+		s.failed++
+	}
+	s.mu.Unlock()
+	if hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if !healed {
+		return errors.New("sink unavailable")
+	}
+	return s.memorySink.Write(ctx, exposures)
+}
+
+func TestExposureLoggerSinkFailure(t *testing.T) {
+	t.Parallel()
+	sink := &flakySink{memorySink: newMemorySink()}
+	l, err := NewExposureLogger(ExposureConfig{Sink: sink, FlushInterval: time.Hour, MaxPending: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l.Close()
+
+	l.Log(Exposure{Flag: "a", Subject: "u1"})
+	l.Log(Exposure{Flag: "a", Subject: "u2"})
+	if err = l.Flush(context.Background()); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+	// already seen, so only the kept batch can deliver it
+	l.Log(Exposure{Flag: "a", Subject: "u1"})
+	l.Log(Exposure{Flag: "a", Subject: "u3"})
+	l.Log(Exposure{Flag: "a", Subject: "u4"})
+
+	sink.mu.Lock()
+	sink.healed = true
+	sink.mu.Unlock()
+	if err = l.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var subjects []string
+	for _, e := range sink.exposures() {
+		subjects = append(subjects, e.Subject)
+	}
+	// MaxPending drops the oldest
+	if diff := cmp.Diff([]string{"u2", "u3", "u4"}, subjects); diff != "" {
+		t.Errorf("unexpected exposures (-want +got):\n%s", diff)
+	}
+}
+
+func TestExposureLoggerCloseTimeout(t *testing.T) {
+	t.Parallel()
+	sink := &flakySink{memorySink: newMemorySink(), hang: true}
+	l, err := NewExposureLogger(ExposureConfig{Sink: sink, FlushInterval: time.Hour, FlushTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l.Log(Exposure{Flag: "a", Subject: "u1"})
+
+	closed := make(chan struct{})
+	go func() {
+		l.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Close to give up on a hung sink")
+	}
+}
+
+func TestExposureLoggerHook(t *testing.T) {
+	sink := newMemorySink()
+	l, err := NewExposureLogger(ExposureConfig{Sink: sink, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = NewClient(Config{
+		Evaluator:           NewInMemory(map[string]any{numberFlagName: 42}),
+		Hooks:               []Hook{l},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	_, _ = GetInt(numberFlagName, "user", 1)
+	_, _ = GetInt(notExistsFlagName, "user", 1)
+	Close()
+
+	got := sink.exposures()
+	if len(got) != 1 {
+		t.Fatalf("expected Close to flush 1 exposure, got %d", len(got))
+	}
+	if got[0].Flag != numberFlagName || got[0].Subject != "user" || got[0].Variation != variationStatic {
+		t.Errorf("unexpected exposure: %+v", got[0])
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "exposures.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = sink.Write(context.Background(), []Exposure{{Flag: "a"}, {Flag: "b"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = sink.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	var flags []string
+	for s.Scan() {
+		var e Exposure
+		if err = json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("unexpected error decoding line %q: %v", s.Text(), err)
+		}
+		flags = append(flags, e.Flag)
+	}
+	if len(flags) != 2 || flags[0] != "a" || flags[1] != "b" {
+		t.Errorf("expected a and b, got %v", flags)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got []Exposure
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := NewHTTPSink(srv.URL, nil).Write(context.Background(), []Exposure{{Flag: "a"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(got) != 1 || got[0].Flag != "a" {
+				t.Errorf("expected the batch to be posted, got %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"gopkg.in/yaml.v3"
+)
+
+// flagFile is the raw content of a goff flag file keyed by flag name.
+type flagFile map[string]map[string]any
+
+func parseFlagFile(b []byte, format string) (flagFile, error) {
+	var f flagFile
+	var err error
+	switch strings.ToLower(format) {
+	case "json":
+		err = json.Unmarshal(b, &f)
+	case "toml":
+		err = toml.Unmarshal(b, &f)
+	default:
+		err = yaml.Unmarshal(b, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s flag file: %w", format, err)
+	}
+	return f, nil
+}
+
+func marshalFlagFile(f flagFile, format string) ([]byte, error) {
+	var b []byte
+	var err error
+	switch strings.ToLower(format) {
+	case "json":
+		b, err = json.Marshal(f)
+	case "toml":
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(f)
+		b = buf.Bytes()
+	default:
+		b, err = yaml.Marshal(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s flag file: %w", format, err)
+	}
+	return b, nil
+}
+
+// loadFlagFile reads every retriever and merges them the way goff does,
+// flags in later retrievers replace the ones in earlier ones.
+func loadFlagFile(ctx context.Context, retrievers []retriever.Retriever, format string) (flagFile, error) {
+	merged := flagFile{}
+	for _, r := range retrievers {
+		b, err := r.Retrieve(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve flags: %w", err)
+		}
+		f, err := parseFlagFile(b, format)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range f {
+			merged[k] = v
+		}
+	}
+	return merged, nil
+}
+
+func (f flagFile) variations(flag string) (map[string]any, bool) {
+	def, ok := f[flag]
+	if !ok {
+		return nil, false
+	}
+	v, _ := def["variations"].(map[string]any)
+	return v, true
+}
+
+func variationKind(v any) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case int, int64, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -1,13 +1,18 @@
 package flags
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	ffclient "github.com/thomaspoignant/go-feature-flag"
 	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/notifier"
 	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
 	"golang.org/x/exp/slices"
 )
 
@@ -15,30 +20,361 @@ type Config struct {
 	PollingInterval time.Duration
 	Retrievers      []retriever.Retriever
 	FileFormat      string
+	// Evaluator replaces the in-process goff client, e.g. with a remote.Client
+	Evaluator Evaluator
+	// Overrides are checked in order before the base evaluator, see Layered
+	Overrides []Evaluator
+	// DisableEnvOverrides ignores FLAGS_OVERRIDE_* env vars, see EnvOverrides.
+	// They are checked after any FeatureOverrides and ahead of the rest, so
+	// command-line flags beat the env, which beats the flag file
+	DisableEnvOverrides bool
+	// Tracer (optional) gets an event for every evaluation
+	Tracer Tracer
+	// Metrics (optional) counts evaluations, errors and refreshes, see Registry
+	Metrics Metrics
+	// Logger (optional) is used for init, refreshes and evaluation errors,
+	// repeated errors are only logged once a minute. Default: slog.Default()
+	Logger *slog.Logger
+	// Hooks run around every evaluation, before the built-in metrics,
+	// logging and tracing
+	Hooks []Hook
+
+	// The rest is passed through to goff, see ffclient.Config
+
+	// EnablePollingJitter spreads refreshes by up to 10% of PollingInterval
+	EnablePollingJitter bool
+	// Environment is available to targeting rules as "env"
+	Environment string
+	// Notifiers are told about flag changes on every refresh
+	Notifiers             []notifier.Notifier
+	DisableNotifierOnInit bool
+	// DataExporters export evaluation events, e.g. to S3 or a webhook
+	DataExporters []ffclient.DataExporter
+	// Offline serves defaults for every flag without calling the retrievers
+	Offline bool
+	// StartWithRetrieverError starts with an empty flag set, serving
+	// defaults, when the retrievers fail on init instead of failing NewClient
+	StartWithRetrieverError bool
+	// Retry (optional) retries failed retrievals on init and refresh
+	Retry *RetryPolicy
+	// CacheFile (optional) persists every successfully retrieved flag set,
+	// a retriever that fails on startup is served from it instead, with
+	// ReasonCached
+	CacheFile string
+	// MaxStaleness (optional) is how old the last successful refresh may
+	// get before StalePolicy applies and HealthHandler reports degraded.
+	// Flags served from the CacheFile or the Bootstrap don't count as
+	// refreshed, so it needs Retrievers
+	MaxStaleness time.Duration
+	StalePolicy  StalePolicy
+	// StaleFlags sets the StalePolicy per flag, e.g. to fail closed on a
+	// dangerous flag while everything else keeps serving
+	StaleFlags map[string]StalePolicy
+	// Bootstrap (optional) is the lowest priority retriever, e.g. an
+	// FSRetriever over an embedded snapshot. Its flags are served until
+	// every other retriever has loaded
+	Bootstrap retriever.Retriever
+	// WatchFiles refreshes as soon as the files of file or dir retrievers
+	// change, instead of waiting for the PollingInterval, see FileWatcher
+	WatchFiles bool
+	// AsyncInit returns from NewClient straight away, getters serve defaults
+	// with ReasonNotReady until the flags are loaded, see Ready and WaitReady
+	AsyncInit bool
 }
 
-func NewClient(cfg Config) error {
-	if cfg.Retrievers == nil {
+var fileFormats = []string{"yaml", "json", "toml"}
+
+func (cfg Config) validate() error {
+	if cfg.PollingInterval < 0 {
+		return fmt.Errorf("invalid polling interval %s", cfg.PollingInterval)
+	}
+	if cfg.FileFormat != "" && !slices.Contains(fileFormats, strings.ToLower(cfg.FileFormat)) {
+		return fmt.Errorf("invalid file format %q, expected one of %v", cfg.FileFormat, fileFormats)
+	}
+	if cfg.Retrievers == nil && cfg.Bootstrap == nil && !cfg.Offline {
 		return fmt.Errorf("ffclient expects at least 1 retriever")
 	}
+	if cfg.MaxStaleness < 0 {
+		return fmt.Errorf("invalid max staleness %s", cfg.MaxStaleness)
+	}
+	if cfg.MaxStaleness > 0 && len(cfg.Retrievers) == 0 {
+		return fmt.Errorf("max staleness needs at least 1 retriever, bootstrap flags never refresh")
+	}
+	polling := pollingInterval(cfg.PollingInterval)
+	if cfg.MaxStaleness > 0 && cfg.MaxStaleness <= polling {
+		return fmt.Errorf("max staleness %s must be longer than the polling interval %s", cfg.MaxStaleness, polling)
+	}
+	for i, r := range cfg.Retrievers {
+		if r == nil {
+			return fmt.Errorf("retriever %d is nil", i)
+		}
+	}
+	for i, n := range cfg.Notifiers {
+		if n == nil {
+			return fmt.Errorf("notifier %d is nil", i)
+		}
+	}
+	for i, e := range cfg.DataExporters {
+		switch {
+		case e.Exporter == nil:
+			return fmt.Errorf("data exporter %d has no exporter", i)
+		case e.FlushInterval < 0:
+			return fmt.Errorf("data exporter %d has an invalid flush interval %s", i, e.FlushInterval)
+		case e.MaxEventInMemory < 0:
+			return fmt.Errorf("data exporter %d has an invalid max events in memory %d", i, e.MaxEventInMemory)
+		}
+	}
+	return nil
+}
 
-	format := "yaml"
-	if cfg.FileFormat != "" {
-		format = cfg.FileFormat
+func NewClient(cfg Config) error {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !cfg.DisableEnvOverrides {
+		if env := NewEnvOverrides(); env.Len() > 0 {
+			env.log(logger)
+			cfg.Overrides = withEnvOverrides(cfg.Overrides, env)
+		}
+	}
+	limited := newLimitedLogger(logger)
+	if cfg.Evaluator != nil {
+		s := newStartup()
+		begin(s)
+		install(cfg, cfg.Evaluator, s)
+		s.markReady()
+		return nil
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	cfg.Retrievers = withEnvironment(cfg.Retrievers, cfg.Environment)
+
+	var r *retrier
+	if cfg.Retry != nil {
+		r = newRetrier(*cfg.Retry, cfg.Metrics, limited)
+	}
+	var lkg *lastKnownGood
+	var base Evaluator = singleton{}
+	if cfg.CacheFile != "" {
+		lkg = newLastKnownGood(cfg.CacheFile, fileFormat(cfg), limited)
+		base = cachedEvaluator{Evaluator: base, cache: lkg}
+	}
+	revs := newRevisions(cfg.Retrievers, fileFormat(cfg))
+	if revs != nil {
+		base = revisionEvaluator{Evaluator: base, revs: revs}
+	}
+	// inside the cache and bootstrap, so flags served from those don't
+	// count as refreshed
+	tracker := newRefreshTracker(len(cfg.Retrievers), fileFormat(cfg), cfg.Metrics, limited)
+	var st *staleness
+	if cfg.MaxStaleness > 0 {
+		st = newStaleness(base, cfg, tracker.lastRefresh)
+		base = st
+	}
+	s := newStartup()
+	s.retrier, s.stale = r, st
+	if cfg.WatchFiles {
+		w, err := watchRetrievers(cfg, s)
+		if err != nil {
+			return err
+		}
+		s.watcher = w
+	}
+	begin(s)
+	if cfg.AsyncInit {
+		install(cfg, notReady{}, s)
+		go func() {
+			if err := initGoff(cfg, logger, limited, r, tracker, lkg, revs); err != nil {
+				s.fail(err)
+				return
+			}
+			if install(cfg, base, s) {
+				s.markReady()
+				return
+			}
+			// Close or another NewClient came first, don't leak goff
+			defaultMu.RLock()
+			inUse := goffStarted
+			defaultMu.RUnlock()
+			if !inUse {
+				ffclient.Close()
+			}
+		}()
+		return nil
+	}
+	if err := initGoff(cfg, logger, limited, r, tracker, lkg, revs); err != nil {
+		s.fail(err)
+		s.stop()
+		return err
+	}
+	install(cfg, base, s)
+	s.markReady()
+	return nil
+}
+
+// pollingInterval is how often goff actually polls: every minute by default
+// and at most once a second.
+func pollingInterval(d time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return time.Minute
+	case d < time.Second:
+		return time.Second
+	}
+	return d
+}
+
+func fileFormat(cfg Config) string {
+	if cfg.FileFormat == "" {
+		return "yaml"
+	}
+	return strings.ToLower(cfg.FileFormat)
+}
+
+func initGoff(
+	cfg Config,
+	logger *slog.Logger,
+	limited *limitedLogger,
+	r *retrier,
+	tracker *refreshTracker,
+	lkg *lastKnownGood,
+	revs *revisions,
+) error {
+	format := fileFormat(cfg)
+	// retries happen before a refresh counts as failed, and only a failed
+	// refresh falls back to the cache. Validating the overrides loads the
+	// flags too, so it retries the same way.
+	if r != nil {
+		cfg.Retrievers = r.wrap(cfg.Retrievers)
+	}
+	if err := validateOverrides(cfg, format, logger); err != nil {
+		return err
+	}
+	retrievers := tracker.wrap(cfg.Retrievers)
+	// before the cache, flags served from it have no revision
+	if revs != nil {
+		retrievers = revs.wrap(retrievers)
+	}
+	if lkg != nil {
+		retrievers = lkg.wrap(retrievers)
+	}
+	if cfg.Bootstrap != nil {
+		retrievers = withBootstrap(cfg.Bootstrap, retrievers, format, limited)
 	}
 	err := ffclient.Init(ffclient.Config{
-		PollingInterval: cfg.PollingInterval,
-		Retrievers:      cfg.Retrievers,
-		FileFormat:      format,
+		PollingInterval:         cfg.PollingInterval,
+		Retrievers:              retrievers,
+		FileFormat:              format,
+		LeveledLogger:           cfg.Logger,
+		EnablePollingJitter:     cfg.EnablePollingJitter,
+		Environment:             cfg.Environment,
+		Notifiers:               cfg.Notifiers,
+		DisableNotifierOnInit:   cfg.DisableNotifierOnInit,
+		DataExporters:           cfg.DataExporters,
+		Offline:                 cfg.Offline,
+		StartWithRetrieverError: cfg.StartWithRetrieverError,
 	})
 	if err != nil {
+		// goff's Init only runs once until Close, a later NewClient would
+		// otherwise be a no-op serving defaults
+		ffclient.Close()
+		logger.Error("failed to init flags", "error", err)
 		return fmt.Errorf("failed to init goff: %v", err)
 	}
+	logger.Info("flags initialised", "retrievers", len(retrievers), "format", format)
 	return nil
 }
 
+type validator interface {
+	validate(flagFile) error
+}
+
+// validateOverrides checks overrides that know the flag file they apply to,
+// such as FeatureOverrides, before anything is evaluated against them.
+func validateOverrides(cfg Config, format string, logger *slog.Logger) error {
+	var file flagFile
+	for _, o := range cfg.Overrides {
+		v, ok := o.(validator)
+		if !ok {
+			continue
+		}
+		if file == nil {
+			retrievers := cfg.Retrievers
+			if cfg.Bootstrap != nil {
+				retrievers = append([]retriever.Retriever{cfg.Bootstrap}, retrievers...)
+			}
+			f, err := preload(retrievers, format, logger)
+			if err != nil && (cfg.StartWithRetrieverError || cfg.Offline || cfg.CacheFile != "" || cfg.Bootstrap != nil) {
+				logger.Warn("skipping flag override validation, flags could not be loaded", "error", err)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load flags to validate overrides: %w", err)
+			}
+			file = f
+		}
+		if err := v.validate(file); err != nil {
+			return fmt.Errorf("invalid flag overrides: %w", err)
+		}
+	}
+	return nil
+}
+
+// preload loads the flags before goff does, so retrievers that need Init
+// are started for it and shut down again.
+func preload(retrievers []retriever.Retriever, format string, logger *slog.Logger) (flagFile, error) {
+	ctx := context.Background()
+	shutdown, err := initRetrievers(ctx, retrievers, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer shutdown()
+	return loadFlagFile(ctx, retrievers, format)
+}
+
+// withEnvOverrides puts env right after the last FeatureOverrides.
+func withEnvOverrides(overrides []Evaluator, env *EnvOverrides) []Evaluator {
+	i := 0
+	for j, o := range overrides {
+		if _, ok := o.(*FeatureOverrides); ok {
+			i = j + 1
+		}
+	}
+	return slices.Insert(slices.Clone(overrides), i, Evaluator(env))
+}
+
+func withOverrides(base Evaluator, overrides []Evaluator) Evaluator {
+	if len(overrides) == 0 {
+		return base
+	}
+	return NewLayered(base, overrides...)
+}
+
+type closer interface {
+	Close()
+}
+
 func Close() {
-	ffclient.Close()
+	defaultMu.Lock()
+	e := defaultEvaluator
+	defaultEvaluator = singleton{}
+	goffStarted = false
+	prev := current
+	current = newStartup()
+	hs := hooks
+	hooks = nil
+	defaultMu.Unlock()
+	prev.stop()
+	if c, ok := e.(closer); ok {
+		c.Close()
+	}
+	for _, h := range hs {
+		if c, ok := h.(closer); ok {
+			c.Close()
+		}
+	}
 }
 
 func IsEnabledByID(
@@ -47,32 +383,48 @@ func IsEnabledByID(
 	id,
 	lookup string,
 	defaultValue bool,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (bool, error) {
+	return IsEnabledByIDContext(context.Background(), flag, userID, id, lookup, defaultValue, client...)
+}
+
+func IsEnabledByIDContext(
+	ctx context.Context,
+	flag,
+	userID,
+	id,
+	lookup string,
+	defaultValue bool,
+	client ...Evaluator,
 ) (bool, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContextBuilder(userID).AddCustom(lookup, id).Build()
-	if len(client) > 0 && client[0] != nil {
-		return client[0].BoolVariation(flag, c, defaultValue)
-	}
-	return ffclient.BoolVariation(flag, c, defaultValue)
+	return evaluate(ctx, flag, c, defaultValue, evaluatorFor(client).BoolVariationDetails)
 }
 
 func IsEnabled(
 	flag,
 	userID string,
 	defaultValue bool,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (bool, error) {
+	return IsEnabledContext(context.Background(), flag, userID, defaultValue, client...)
+}
+
+func IsEnabledContext(
+	ctx context.Context,
+	flag,
+	userID string,
+	defaultValue bool,
+	client ...Evaluator,
 ) (bool, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContext(userID)
-	if len(client) > 0 && client[0] != nil {
-		return client[0].BoolVariation(flag, c, defaultValue)
-	}
-	return ffclient.BoolVariation(flag, c, defaultValue)
+	return evaluate(ctx, flag, c, defaultValue, evaluatorFor(client).BoolVariationDetails)
 }
 
 func GetTime(
@@ -80,20 +432,24 @@ func GetTime(
 	userID,
 	layout string,
 	defaultValue time.Time,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (time.Time, error) {
+	return GetTimeContext(context.Background(), flag, userID, layout, defaultValue, client...)
+}
+
+func GetTimeContext(
+	ctx context.Context,
+	flag,
+	userID,
+	layout string,
+	defaultValue time.Time,
+	client ...Evaluator,
 ) (time.Time, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContext(userID)
-	var s string
-	var clientErr error
-	if len(client) > 0 && client[0] != nil {
-		s, clientErr = client[0].StringVariation(flag, c, defaultValue.Format(layout))
-	} else {
-		s, clientErr = ffclient.StringVariation(flag, c, defaultValue.Format(layout))
-	}
-
+	s, clientErr := evaluate(ctx, flag, c, defaultValue.Format(layout), evaluatorFor(client).StringVariationDetails)
 	if clientErr != nil {
 		return defaultValue, fmt.Errorf("failed to get flag %s: %w", flag, clientErr)
 	}
@@ -108,57 +464,86 @@ func GetInt(
 	flag,
 	userID string,
 	defaultValue int,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (int, error) {
+	return GetIntContext(context.Background(), flag, userID, defaultValue, client...)
+}
+
+func GetIntContext(
+	ctx context.Context,
+	flag,
+	userID string,
+	defaultValue int,
+	client ...Evaluator,
 ) (int, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContext(userID)
-	if len(client) > 0 && client[0] != nil {
-		return client[0].IntVariation(flag, c, defaultValue)
-	}
-	return ffclient.IntVariation(flag, c, defaultValue)
+	return evaluate(ctx, flag, c, defaultValue, evaluatorFor(client).IntVariationDetails)
 }
 
 func GetFloat(
 	flag,
 	userID string,
 	defaultValue float64,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (float64, error) {
+	return GetFloatContext(context.Background(), flag, userID, defaultValue, client...)
+}
+
+func GetFloatContext(
+	ctx context.Context,
+	flag,
+	userID string,
+	defaultValue float64,
+	client ...Evaluator,
 ) (float64, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContext(userID)
-
-	if len(client) > 0 && client[0] != nil {
-		return client[0].Float64Variation(flag, c, defaultValue)
-	}
-
-	return ffclient.Float64Variation(flag, c, defaultValue)
+	return evaluate(ctx, flag, c, defaultValue, evaluatorFor(client).Float64VariationDetails)
 }
 
 func GetString(
 	flag,
 	userID string,
 	defaultValue string,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (string, error) {
+	return GetStringContext(context.Background(), flag, userID, defaultValue, client...)
+}
+
+func GetStringContext(
+	ctx context.Context,
+	flag,
+	userID string,
+	defaultValue string,
+	client ...Evaluator,
 ) (string, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContext(userID)
-	if len(client) > 0 && client[0] != nil {
-		return client[0].StringVariation(flag, c, defaultValue)
-	}
-	return ffclient.StringVariation(flag, c, defaultValue)
+	return evaluate(ctx, flag, c, defaultValue, evaluatorFor(client).StringVariationDetails)
 }
 
 func GetJSONStruct[T any](
 	flag,
 	userID string,
 	defaultValue T,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (T, error) {
+	return GetJSONStructContext(context.Background(), flag, userID, defaultValue, client...)
+}
+
+func GetJSONStructContext[T any](
+	ctx context.Context,
+	flag,
+	userID string,
+	defaultValue T,
+	client ...Evaluator,
 ) (T, error) {
 	if userID == "" {
 		userID = "anonymous"
@@ -175,13 +560,7 @@ func GetJSONStruct[T any](
 		return defaultValue, fmt.Errorf("failed to unmarshal default value to map: %w", err)
 	}
 
-	var j map[string]any
-	var clientErr error
-	if len(client) > 0 && client[0] != nil {
-		j, clientErr = client[0].JSONVariation(flag, c, defaultMap)
-	} else {
-		j, clientErr = ffclient.JSONVariation(flag, c, defaultMap)
-	}
+	j, clientErr := evaluate(ctx, flag, c, defaultMap, evaluatorFor(client).JSONVariationDetails)
 	if clientErr != nil {
 		return defaultValue, fmt.Errorf("failed to get flag %s: %w", flag, clientErr)
 	}
@@ -203,16 +582,23 @@ func GetJSONMap(
 	flag,
 	userID string,
 	defaultValue map[string]any,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (map[string]any, error) {
+	return GetJSONMapContext(context.Background(), flag, userID, defaultValue, client...)
+}
+
+func GetJSONMapContext(
+	ctx context.Context,
+	flag,
+	userID string,
+	defaultValue map[string]any,
+	client ...Evaluator,
 ) (map[string]any, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContext(userID)
-	if len(client) > 0 && client[0] != nil {
-		return client[0].JSONVariation(flag, c, defaultValue)
-	}
-	return ffclient.JSONVariation(flag, c, defaultValue)
+	return evaluate(ctx, flag, c, defaultValue, evaluatorFor(client).JSONVariationDetails)
 }
 
 func IsEnabledByIDList[T comparable](
@@ -220,19 +606,24 @@ func IsEnabledByIDList[T comparable](
 	userID string,
 	lookup T,
 	defaultValue bool,
-	client ...*ffclient.GoFeatureFlag,
+	client ...Evaluator,
+) (bool, error) {
+	return IsEnabledByIDListContext(context.Background(), flag, userID, lookup, defaultValue, client...)
+}
+
+func IsEnabledByIDListContext[T comparable](
+	ctx context.Context,
+	flag,
+	userID string,
+	lookup T,
+	defaultValue bool,
+	client ...Evaluator,
 ) (bool, error) {
 	if userID == "" {
 		userID = "anonymous"
 	}
 	c := ffcontext.NewEvaluationContext(userID)
-	var l []any
-	var clientErr error
-	if len(client) > 0 && client[0] != nil {
-		l, clientErr = client[0].JSONArrayVariation(flag, c, []any{})
-	} else {
-		l, clientErr = ffclient.JSONArrayVariation(flag, c, []any{})
-	}
+	l, clientErr := evaluate(ctx, flag, c, []any{}, evaluatorFor(client).JSONArrayVariationDetails)
 	if clientErr != nil {
 		return defaultValue, clientErr
 	}
@@ -255,11 +646,63 @@ func IsEnabledByIDList[T comparable](
 	return false, nil
 }
 
-func Refresh(client ...*ffclient.GoFeatureFlag) {
-	if len(client) > 0 && client[0] != nil {
-		client[0].ForceRefresh()
-		return
+type refresher interface {
+	ForceRefresh() bool
+}
+
+func Refresh(client ...Evaluator) {
+	if r, ok := evaluatorFor(client).(refresher); ok {
+		r.ForceRefresh()
 	}
-	ffclient.ForceRefresh()
-	return
+}
+
+// withEnvironment sets the environment of overlay retrievers that don't
+// have their own.
+func withEnvironment(retrievers []retriever.Retriever, env string) []retriever.Retriever {
+	if retrievers == nil {
+		return nil
+	}
+	out := make([]retriever.Retriever, 0, len(retrievers))
+	for _, r := range retrievers {
+		if o, ok := r.(*OverlayRetriever); ok && o.Environment == "" {
+			r = &OverlayRetriever{Path: o.Path, Environment: env, Format: o.Format}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// watchRetrievers watches the files of the file and dir retrievers in cfg
+// and refreshes once s is ready.
+func watchRetrievers(cfg Config, s *startup) (*FileWatcher, error) {
+	var paths []string
+	var dirs []WatchDir
+	for _, r := range append([]retriever.Retriever{cfg.Bootstrap}, cfg.Retrievers...) {
+		switch f := r.(type) {
+		case *fileretriever.Retriever:
+			paths = append(paths, f.Path)
+		case *OverlayRetriever:
+			paths = append(paths, f.Path)
+			if f.Environment != "" {
+				paths = append(paths, OverlayPath(f.Path, f.Environment))
+			}
+		case *DirRetriever:
+			dirs = append(dirs, WatchDir{Path: f.Dir, Recursive: f.Recursive})
+		}
+	}
+	if len(paths) == 0 && len(dirs) == 0 {
+		return nil, nil
+	}
+	return NewFileWatcher(FileWatcherConfig{
+		Paths:  paths,
+		Dirs:   dirs,
+		Logger: cfg.Logger,
+		OnChange: func() {
+			select {
+			case <-s.ready:
+				Refresh()
+			default:
+			}
+		},
+	})
 }
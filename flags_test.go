@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/notifier"
 	"github.com/thomaspoignant/go-feature-flag/retriever"
 	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
 )
@@ -538,3 +539,107 @@ func TestNewClient(t *testing.T) {
 		}
 	})
 }
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+	file := []retriever.Retriever{&fileretriever.Retriever{Path: yamlFlagFileName}}
+	tests := []struct {
+		name      string
+		cfg       Config
+		expectErr bool
+	}{
+		{name: "valid", cfg: Config{Retrievers: file, FileFormat: "YAML"}},
+		{name: "offline without retrievers", cfg: Config{Offline: true}},
+		{name: "bootstrap without retrievers", cfg: Config{Bootstrap: file[0]}},
+		{name: "no retrievers", cfg: Config{}, expectErr: true},
+		{name: "nil retriever", cfg: Config{Retrievers: []retriever.Retriever{nil}}, expectErr: true},
+		{name: "negative polling interval", cfg: Config{Retrievers: file, PollingInterval: -time.Second}, expectErr: true},
+		{name: "unknown file format", cfg: Config{Retrievers: file, FileFormat: "xml"}, expectErr: true},
+		{
+			name:      "max staleness with only a bootstrap",
+			cfg:       Config{Bootstrap: file[0], MaxStaleness: time.Hour},
+			expectErr: true,
+		},
+		{name: "negative max staleness", cfg: Config{Retrievers: file, MaxStaleness: -time.Second}, expectErr: true},
+		{
+			name:      "max staleness within polling interval",
+			cfg:       Config{Retrievers: file, PollingInterval: time.Minute, MaxStaleness: 30 * time.Second},
+			expectErr: true,
+		},
+		{
+			// goff polls at most once a second
+			name:      "max staleness within the clamped polling interval",
+			cfg:       Config{Retrievers: file, PollingInterval: 100 * time.Millisecond, MaxStaleness: 500 * time.Millisecond},
+			expectErr: true,
+		},
+		{name: "nil notifier", cfg: Config{Retrievers: file, Notifiers: []notifier.Notifier{nil}}, expectErr: true},
+		{
+			name:      "data exporter without exporter",
+			cfg:       Config{Retrievers: file, DataExporters: []ffclient.DataExporter{{FlushInterval: time.Second}}},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.cfg.validate()
+			if tt.expectErr && err == nil {
+				t.Errorf("expected error but got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewClientStartWithRetrieverError(t *testing.T) {
+	ffclient.Close()
+	err := NewClient(Config{
+		PollingInterval: 10 * time.Second,
+		Retrievers: []retriever.Retriever{
+			&fileretriever.Retriever{Path: "non-existent.goff.yaml"},
+		},
+		StartWithRetrieverError: true,
+		DisableEnvOverrides:     true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer Close()
+
+	got, err := GetInt(numberFlagName, "user", 7)
+	if err == nil {
+		t.Errorf("expected an error for a flag that wasn't loaded")
+	}
+	if got != 7 {
+		t.Errorf("expected default 7, got %d", got)
+	}
+}
+
+func TestNewClientAfterFailure(t *testing.T) {
+	ffclient.Close()
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&fileretriever.Retriever{Path: "non-existent.goff.yaml"}},
+		DisableEnvOverrides: true,
+	})
+	if err == nil {
+		Close()
+		t.Fatal("expected a missing flag file to fail NewClient")
+	}
+
+	err = NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&fileretriever.Retriever{Path: yamlFlagFileName}},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got == 7 {
+		t.Errorf("expected the flags to be loaded, got %d, %v", got, err)
+	}
+}
@@ -0,0 +1,77 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+// FSRetriever reads a flag file from any fs.FS, e.g. an embed.FS shipped
+// in the binary as Config.Bootstrap or a fstest.MapFS in tests.
+type FSRetriever struct {
+	FS   fs.FS
+	Path string
+}
+
+func (r *FSRetriever) Retrieve(_ context.Context) ([]byte, error) {
+	b, err := fs.ReadFile(r.FS, r.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flag file %s: %w", r.Path, err)
+	}
+	return b, nil
+}
+
+// emptyFlagFile is an empty flag set in the given format.
+func emptyFlagFile(format string) []byte {
+	if format == "toml" {
+		return []byte{}
+	}
+	return []byte("{}")
+}
+
+// withBootstrap puts bootstrap before the retrievers, so any of their flags
+// take precedence, and has the retrievers return an empty flag set until
+// they first succeed so goff starts with the bootstrap flags alone. Once
+// loaded, a failing retriever errors as usual and goff keeps its flags. Once
+// every retriever has loaded the bootstrap returns an empty flag set too, so
+// a flag deleted from them isn't served from the bootstrap. goff retrieves
+// in parallel, so that takes effect from the refresh after they loaded.
+func withBootstrap(bootstrap retriever.Retriever, retrievers []retriever.Retriever, format string, logger *limitedLogger) []retriever.Retriever {
+	var mu sync.Mutex
+	loaded := map[int]bool{}
+	wrapped := []retriever.Retriever{wrapRetriever(bootstrap, func(next retrieveFunc) retrieveFunc {
+		return func(ctx context.Context) ([]byte, error) {
+			mu.Lock()
+			done := len(retrievers) > 0 && len(loaded) == len(retrievers)
+			mu.Unlock()
+			if done {
+				return emptyFlagFile(format), nil
+			}
+			return next(ctx)
+		}
+	})}
+	for i, r := range retrievers {
+		wrapped = append(wrapped, wrapRetriever(r, func(next retrieveFunc) retrieveFunc {
+			return func(ctx context.Context) ([]byte, error) {
+				b, err := next(ctx)
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					loaded[i] = true
+					return b, nil
+				}
+				if loaded[i] {
+					return nil, err
+				}
+				logger.log(slog.LevelWarn, "bootstrap/"+strconv.Itoa(i), "flag retriever unavailable, using bootstrap flags", "retriever", i, "error", err)
+				return emptyFlagFile(format), nil
+			}
+		}))
+	}
+	return wrapped
+}
@@ -0,0 +1,142 @@
+package flags
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+const bootstrapFlags = `
+ff-number:
+  variations:
+    bootstrap: 1
+  defaultRule:
+    variation: bootstrap
+bootstrap-only:
+  variations:
+    on: true
+  defaultRule:
+    variation: on
+`
+
+func TestFSRetriever(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{"flags/flags.goff.yaml": {Data: []byte(bootstrapFlags)}}
+	tests := []struct {
+		name      string
+		path      string
+		expectErr bool
+	}{
+		{name: "found", path: "flags/flags.goff.yaml"},
+		{name: "missing", path: "flags/missing.goff.yaml", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := (&FSRetriever{FS: fsys, Path: tt.path}).Retrieve(context.Background())
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(b) != bootstrapFlags {
+				t.Errorf("unexpected flag file %q", b)
+			}
+		})
+	}
+}
+
+func TestNewClientBootstrap(t *testing.T) {
+	bootstrap := &FSRetriever{
+		FS:   fstest.MapFS{"flags.goff.yaml": {Data: []byte(bootstrapFlags)}},
+		Path: "flags.goff.yaml",
+	}
+	tests := []struct {
+		name      string
+		retriever retriever.Retriever
+		want      int
+	}{
+		{name: "retriever down", retriever: failingRetriever{}, want: 1},
+		{name: "retriever takes precedence", retriever: &fileretriever.Retriever{Path: yamlFlagFileName}, want: 9081},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ffclient.Close()
+			err := NewClient(Config{
+				PollingInterval:     10 * time.Second,
+				Retrievers:          []retriever.Retriever{tt.retriever},
+				Bootstrap:           bootstrap,
+				DisableEnvOverrides: true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating client: %v", err)
+			}
+			defer Close()
+
+			got, err := GetInt(numberFlagName, "user", 7)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewClientBootstrapRetrieverDown(t *testing.T) {
+	ffclient.Close()
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{failingRetriever{}},
+		Bootstrap:           &FSRetriever{FS: fstest.MapFS{"flags.goff.yaml": {Data: []byte(bootstrapFlags)}}, Path: "flags.goff.yaml"},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	Refresh()
+	if on, err := IsEnabled("bootstrap-only", "user", false); err != nil || !on {
+		t.Errorf("expected the bootstrap only flag to be served, got %v, %v", on, err)
+	}
+}
+
+func TestNewClientBootstrapDeletedFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.goff.yaml")
+	writeFile(t, path, yamlFlag("ff-number", 2)+yamlFlag("deleted", 3))
+	ffclient.Close()
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&fileretriever.Retriever{Path: path}},
+		Bootstrap:           &FSRetriever{FS: fstest.MapFS{"flags.goff.yaml": {Data: []byte(bootstrapFlags)}}, Path: "flags.goff.yaml"},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	writeFile(t, path, yamlFlag("ff-number", 2))
+	Refresh()
+	if got, err := GetInt("deleted", "user", 7); err == nil || got != 7 {
+		t.Errorf("expected the deleted flag to be gone, got %d, %v", got, err)
+	}
+	if on, err := IsEnabled("bootstrap-only", "user", false); err == nil || on {
+		t.Errorf("expected the bootstrap flags to be gone once loaded, got %v, %v", on, err)
+	}
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 2 {
+		t.Errorf("unexpected value: got %d, %v want 2", got, err)
+	}
+}
@@ -0,0 +1,291 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+// MetadataRevision is the evaluation metadata key holding the revision of
+// the flags a value came from, e.g. the commit a GitRetriever loaded.
+const MetadataRevision = "revision"
+
+// Revisioner is implemented by retrievers that know which revision of the
+// flags they last retrieved.
+type Revisioner interface {
+	Revision() string
+}
+
+// GitRetriever reads a flag file at Ref from a local git repository using
+// the git CLI.
+type GitRetriever struct {
+	// Repo is the path to the repository, or any directory inside it
+	Repo string
+	// Path of the flag file from the root of the repository
+	Path string
+	// Ref is any commit-ish, e.g. a branch, tag or SHA. Default: HEAD
+	Ref string
+	// Fetch runs git fetch before every retrieval, Ref would then usually
+	// be a remote tracking branch such as origin/main
+	Fetch bool
+	// Remote to fetch from. Default: origin
+	Remote string
+	// Git is the git binary. Default: git
+	Git string
+
+	mu       sync.Mutex
+	revision string
+}
+
+func (r *GitRetriever) Retrieve(ctx context.Context) ([]byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if r.Fetch {
+		remote := r.Remote
+		if remote == "" {
+			remote = "origin"
+		}
+		if _, err := r.git(ctx, "fetch", "--quiet", remote); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", remote, err)
+		}
+	}
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	out, err := r.git(ctx, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	sha := strings.TrimSpace(string(out))
+	// read from the resolved commit so the content matches the revision
+	b, err := r.git(ctx, "cat-file", "blob", sha+":"+strings.TrimPrefix(r.Path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", r.Path, ref, err)
+	}
+	r.mu.Lock()
+	r.revision = sha
+	r.mu.Unlock()
+	return b, nil
+}
+
+// Revision is the SHA of the commit the flag file was last read from.
+func (r *GitRetriever) Revision() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.revision
+}
+
+func (r *GitRetriever) git(ctx context.Context, args ...string) ([]byte, error) {
+	bin := r.Git
+	if bin == "" {
+		bin = "git"
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, append([]string{"-C", r.Repo}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// revisions keeps the revision of the flags goff last accepted from each
+// Revisioner. goff retrieves from every retriever and drops the refresh if
+// any of them fails, so a retrieval is only accepted once goff's cache was
+// refreshed after it. It tracks the flags of every retriever, as goff lets
+// the last retriever defining a flag win, so each flag only gets the
+// revision of the retriever it came from.
+type revisions struct {
+	sources     map[int]Revisioner
+	format      string
+	refreshDate func() time.Time
+
+	mu       sync.Mutex
+	pending  map[int]retrieval
+	accepted map[int]retrieval
+}
+
+// retrieval is the result of a single Retrieve.
+type retrieval struct {
+	at time.Time
+	// ok is false if the retrieval failed, goff accepting it anyway means
+	// its flags came from the cache file or the bootstrap
+	ok       bool
+	revision string
+	sum      [sha256.Size]byte
+	flags    []string
+}
+
+// newRevisions returns nil if none of the retrievers are a Revisioner.
+func newRevisions(retrievers []retriever.Retriever, format string) *revisions {
+	sources := map[int]Revisioner{}
+	for i, r := range retrievers {
+		if rev, ok := unwrapRetriever(r).(Revisioner); ok {
+			sources[i] = rev
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	return &revisions{
+		sources:     sources,
+		format:      format,
+		refreshDate: ffclient.GetCacheRefreshDate,
+		pending:     map[int]retrieval{},
+		accepted:    map[int]retrieval{},
+	}
+}
+
+func (v *revisions) wrap(retrievers []retriever.Retriever) []retriever.Retriever {
+	wrapped := make([]retriever.Retriever, 0, len(retrievers))
+	for i, r := range retrievers {
+		wrapped = append(wrapped, wrapRetriever(r, func(next retrieveFunc) retrieveFunc {
+			return func(ctx context.Context) ([]byte, error) {
+				b, err := next(ctx)
+				v.record(i, b, err)
+				return b, err
+			}
+		}))
+	}
+	return wrapped
+}
+
+func (v *revisions) record(i int, b []byte, err error) {
+	r := retrieval{at: time.Now()}
+	if err == nil {
+		r.sum = sha256.Sum256(b)
+		v.mu.Lock()
+		last, ok := v.pending[i]
+		if !ok {
+			last = v.accepted[i]
+		}
+		v.mu.Unlock()
+		if last.ok && last.sum == r.sum {
+			r.flags, r.ok = last.flags, true
+		} else if f, err := parseFlagFile(b, v.format); err == nil {
+			// goff fails the refresh on a file that doesn't parse
+			r.flags, r.ok = slices.Collect(maps.Keys(f)), true
+		}
+		if src, ok := v.sources[i]; ok && r.ok {
+			r.revision = src.Revision()
+		}
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.pending[i] = r
+}
+
+// revision returns the revision flag was last accepted at, empty if it
+// didn't come from a Revisioner or where it came from isn't known.
+func (v *revisions) revision(flag string) string {
+	date := v.refreshDate()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, r := range v.pending {
+		if !date.Before(r.at) {
+			v.accepted[i] = r
+			delete(v.pending, i)
+		}
+	}
+	source := -1
+	for i, r := range v.accepted {
+		if !r.ok && i > source {
+			// a fallback might define flag too, and would win
+			source = i
+		}
+		if r.ok && i > source && slices.Contains(r.flags, flag) {
+			source = i
+		}
+	}
+	if r, ok := v.accepted[source]; ok && r.ok {
+		return r.revision
+	}
+	return ""
+}
+
+// revisionEvaluator adds MetadataRevision to evaluations of flags that came
+// from a Revisioner.
+type revisionEvaluator struct {
+	Evaluator
+	revs *revisions
+}
+
+func (e revisionEvaluator) unwrap() Evaluator {
+	return e.Evaluator
+}
+
+func withRevision[T model.JSONType](v *revisions, flag string, res model.VariationResult[T], err error) (model.VariationResult[T], error) {
+	rev := v.revision(flag)
+	if rev == "" {
+		return res, err
+	}
+	// goff hands out the flag's own metadata map
+	md := maps.Clone(res.Metadata)
+	if md == nil {
+		md = map[string]any{}
+	}
+	md[MetadataRevision] = rev
+	res.Metadata = md
+	return res, err
+}
+
+func (e revisionEvaluator) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	res, err := e.Evaluator.BoolVariationDetails(flag, ctx, defaultValue)
+	return withRevision(e.revs, flag, res, err)
+}
+
+func (e revisionEvaluator) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	res, err := e.Evaluator.IntVariationDetails(flag, ctx, defaultValue)
+	return withRevision(e.revs, flag, res, err)
+}
+
+func (e revisionEvaluator) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	res, err := e.Evaluator.Float64VariationDetails(flag, ctx, defaultValue)
+	return withRevision(e.revs, flag, res, err)
+}
+
+func (e revisionEvaluator) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	res, err := e.Evaluator.StringVariationDetails(flag, ctx, defaultValue)
+	return withRevision(e.revs, flag, res, err)
+}
+
+func (e revisionEvaluator) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	res, err := e.Evaluator.JSONVariationDetails(flag, ctx, defaultValue)
+	return withRevision(e.revs, flag, res, err)
+}
+
+func (e revisionEvaluator) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	res, err := e.Evaluator.JSONArrayVariationDetails(flag, ctx, defaultValue)
+	return withRevision(e.revs, flag, res, err)
+}
+
+func (e revisionEvaluator) ForceRefresh() bool {
+	if r, ok := e.Evaluator.(refresher); ok {
+		return r.ForceRefresh()
+	}
+	return false
+}
+
+func (e revisionEvaluator) Close() {
+	if c, ok := e.Evaluator.(closer); ok {
+		c.Close()
+	}
+}
@@ -0,0 +1,228 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+// gitRepo runs git in dir and returns its trimmed output.
+func gitRepo(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	base := []string{"-C", dir, "-c", "user.name=flags", "-c", "user.email=flags@example.com", "-c", "commit.gpgsign=false"}
+	out, err := exec.Command("git", append(base, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFlags commits content as flags.goff.yaml and returns the commit SHA.
+func commitFlags(t *testing.T, dir, content string) string {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, "flags.goff.yaml"), content)
+	gitRepo(t, dir, "add", "flags.goff.yaml")
+	gitRepo(t, dir, "commit", "--quiet", "-m", "update flags")
+	return gitRepo(t, dir, "rev-parse", "HEAD")
+}
+
+func newGitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitRepo(t, dir, "init", "--quiet", "--initial-branch", "main")
+	return dir
+}
+
+func TestGitRetriever(t *testing.T) {
+	t.Parallel()
+	upstream := newGitRepo(t)
+	first := commitFlags(t, upstream, bootstrapFlags)
+	gitRepo(t, upstream, "tag", "v1")
+	local := filepath.Join(t.TempDir(), "local")
+	gitRepo(t, upstream, "clone", "--quiet", upstream, local)
+	second := commitFlags(t, upstream, yamlFlag("ff-number", 2))
+
+	tests := []struct {
+		name      string
+		r         *GitRetriever
+		want      string
+		revision  string
+		expectErr bool
+	}{
+		{name: "head", r: &GitRetriever{Repo: upstream, Path: "flags.goff.yaml"}, want: yamlFlag("ff-number", 2), revision: second},
+		{name: "tag", r: &GitRetriever{Repo: upstream, Path: "/flags.goff.yaml", Ref: "v1"}, want: bootstrapFlags, revision: first},
+		{name: "sha", r: &GitRetriever{Repo: upstream, Path: "flags.goff.yaml", Ref: first}, want: bootstrapFlags, revision: first},
+		{name: "clone without fetch", r: &GitRetriever{Repo: local, Path: "flags.goff.yaml", Ref: "origin/main"}, want: bootstrapFlags, revision: first},
+		{name: "clone with fetch", r: &GitRetriever{Repo: local, Path: "flags.goff.yaml", Ref: "origin/main", Fetch: true}, want: yamlFlag("ff-number", 2), revision: second},
+		{name: "unknown ref", r: &GitRetriever{Repo: upstream, Path: "flags.goff.yaml", Ref: "nope"}, expectErr: true},
+		{name: "missing file", r: &GitRetriever{Repo: upstream, Path: "nope.yaml"}, expectErr: true},
+		{name: "unknown remote", r: &GitRetriever{Repo: local, Path: "flags.goff.yaml", Fetch: true, Remote: "nope"}, expectErr: true},
+		{name: "not a repo", r: &GitRetriever{Repo: t.TempDir(), Path: "flags.goff.yaml"}, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.r.Retrieve(context.Background())
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error but got nil")
+				}
+				if tt.r.Revision() != "" {
+					t.Errorf("expected no revision after a failure, got %s", tt.r.Revision())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("unexpected flags %q want %q", b, tt.want)
+			}
+			if tt.r.Revision() != tt.revision {
+				t.Errorf("unexpected revision: got %s want %s", tt.r.Revision(), tt.revision)
+			}
+		})
+	}
+}
+
+// detailsHook keeps the details of the last successful evaluation.
+type detailsHook struct {
+	BaseHook
+	mu   sync.Mutex
+	last EvaluationDetails
+}
+
+func (h *detailsHook) After(_ context.Context, _ HookContext, d EvaluationDetails) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = d
+}
+
+func (h *detailsHook) revision() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return revisionOf(h.last.Metadata)
+}
+
+func TestNewClientGitRetriever(t *testing.T) {
+	repo := newGitRepo(t)
+	first := commitFlags(t, repo, bootstrapFlags)
+
+	ffclient.Close()
+	hook := &detailsHook{}
+	sink := newMemorySink()
+	exposures, err := NewExposureLogger(ExposureConfig{Sink: sink})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = NewClient(Config{
+		PollingInterval:     10 * time.Minute,
+		Retrievers:          []retriever.Retriever{&GitRetriever{Repo: repo, Path: "flags.goff.yaml"}},
+		DisableEnvOverrides: true,
+		Hooks:               []Hook{hook, exposures},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 1 {
+		t.Fatalf("unexpected value: got %d, %v want 1", got, err)
+	}
+	if got := hook.revision(); got != first {
+		t.Errorf("unexpected revision: got %s want %s", got, first)
+	}
+	if err = exposures.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error flushing exposures: %v", err)
+	}
+	if got := sink.exposures(); len(got) != 1 || got[0].Revision != first {
+		t.Errorf("expected an exposure with revision %s, got %+v", first, got)
+	}
+
+	second := commitFlags(t, repo, yamlFlag("ff-number", 2))
+	Refresh()
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 2 {
+		t.Fatalf("unexpected value after refresh: got %d, %v want 2", got, err)
+	}
+	if got := hook.revision(); got != second {
+		t.Errorf("unexpected revision after refresh: got %s want %s", got, second)
+	}
+
+	// goff keeps the flags it has, so the revision must not move either
+	commitFlags(t, repo, "ff-number: [")
+	Refresh()
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 2 {
+		t.Fatalf("unexpected value after a bad commit: got %d, %v want 2", got, err)
+	}
+	if got := hook.revision(); got != second {
+		t.Errorf("unexpected revision after a bad commit: got %s want %s", got, second)
+	}
+}
+
+func TestNewClientGitRetrieverWithOthers(t *testing.T) {
+	repo := newGitRepo(t)
+	first := commitFlags(t, repo, yamlFlag("ff-number", 1))
+	path := filepath.Join(t.TempDir(), "flags.goff.yaml")
+	writeFile(t, path, yamlFlag("other", 2))
+
+	ffclient.Close()
+	hook := &detailsHook{}
+	err := NewClient(Config{
+		PollingInterval: 10 * time.Minute,
+		Retrievers: []retriever.Retriever{
+			&GitRetriever{Repo: repo, Path: "flags.goff.yaml"},
+			&fileretriever.Retriever{Path: path},
+		},
+		DisableEnvOverrides: true,
+		Hooks:               []Hook{hook},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	revision := func(flag string) string {
+		t.Helper()
+		if _, err := GetInt(flag, "user", 7); err != nil {
+			t.Fatalf("unexpected error evaluating %s: %v", flag, err)
+		}
+		return hook.revision()
+	}
+	if got := revision(numberFlagName); got != first {
+		t.Errorf("unexpected revision: got %s want %s", got, first)
+	}
+	if got := revision("other"); got != "" {
+		t.Errorf("expected no revision for a flag from the file, got %s", got)
+	}
+
+	// goff drops the whole refresh when the file is gone
+	commitFlags(t, repo, yamlFlag("ff-number", 3))
+	if err = os.Remove(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	Refresh()
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 1 {
+		t.Fatalf("unexpected value after a failed refresh: got %d, %v want 1", got, err)
+	}
+	if got := hook.revision(); got != first {
+		t.Errorf("unexpected revision after a failed refresh: got %s want %s", got, first)
+	}
+
+	// the file now overrides the flag from git
+	writeFile(t, path, yamlFlag("ff-number", 4))
+	Refresh()
+	if got := revision(numberFlagName); got != "" {
+		t.Errorf("expected no revision for a flag the file overrides, got %s", got)
+	}
+}
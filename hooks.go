@@ -0,0 +1,117 @@
+package flags
+
+import (
+	"context"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+// HookContext describes the evaluation a hook runs around. Before hooks may
+// replace or enrich Subject, later hooks and the evaluation see the result.
+type HookContext struct {
+	Flag    string
+	Subject ffcontext.Context
+	Default any
+}
+
+type EvaluationDetails struct {
+	Flag      string
+	Value     any
+	Variation string
+	Reason    string
+	ErrorCode string
+	// Metadata is the flag's metadata, plus MetadataRevision when the
+	// retrievers know the revision of the flags
+	Metadata map[string]any
+	Latency  time.Duration
+	// Err is set when the evaluation failed and Value is the default
+	Err *EvaluationError
+}
+
+// EvaluationError is returned by every getter when the evaluation fails,
+// Code is the goff error code, e.g. FLAG_NOT_FOUND or TYPE_MISMATCH.
+type EvaluationError struct {
+	Flag string
+	Code string
+	Err  error
+}
+
+func (e *EvaluationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EvaluationError) Unwrap() error {
+	return e.Err
+}
+
+// Hook runs around every getter call, hooks run in the order they were
+// configured. An error from Before skips the evaluation and serves the
+// default. After only runs on success, Error only on failure and Finally
+// always runs last.
+type Hook interface {
+	Before(ctx context.Context, hc *HookContext) error
+	After(ctx context.Context, hc HookContext, details EvaluationDetails)
+	Error(ctx context.Context, hc HookContext, err *EvaluationError)
+	Finally(ctx context.Context, hc HookContext, details EvaluationDetails)
+}
+
+// BaseHook can be embedded to only implement the stages a hook needs.
+type BaseHook struct{}
+
+func (BaseHook) Before(context.Context, *HookContext) error { return nil }
+
+func (BaseHook) After(context.Context, HookContext, EvaluationDetails) {}
+
+func (BaseHook) Error(context.Context, HookContext, *EvaluationError) {}
+
+func (BaseHook) Finally(context.Context, HookContext, EvaluationDetails) {}
+
+// hooksFor is the hook chain install sets up, the built-in observers run
+// after the configured hooks so they see any changes to the subject.
+func hooksFor(cfg Config) []Hook {
+	hooks := append([]Hook{}, cfg.Hooks...)
+	if cfg.Metrics != nil {
+		hooks = append(hooks, metricsHook{metrics: cfg.Metrics})
+	}
+	hooks = append(hooks, loggingHook{logger: newLimitedLogger(cfg.Logger)})
+	if cfg.Tracer != nil {
+		hooks = append(hooks, tracingHook{tracer: cfg.Tracer})
+	}
+	return hooks
+}
+
+type metricsHook struct {
+	BaseHook
+	metrics Metrics
+}
+
+func (h metricsHook) Finally(_ context.Context, hc HookContext, d EvaluationDetails) {
+	h.metrics.ObserveEvaluation(hc.Flag, d.Variation, d.Reason, d.Latency)
+}
+
+func (h metricsHook) Error(_ context.Context, _ HookContext, err *EvaluationError) {
+	h.metrics.IncError(errorKind(err.Code))
+}
+
+type loggingHook struct {
+	BaseHook
+	logger *limitedLogger
+}
+
+func (h loggingHook) Error(_ context.Context, hc HookContext, err *EvaluationError) {
+	h.logger.evaluationError(hc.Flag, err.Code, err.Err)
+}
+
+type tracingHook struct {
+	BaseHook
+	tracer Tracer
+}
+
+func (h tracingHook) Finally(ctx context.Context, hc HookContext, d EvaluationDetails) {
+	var err error
+	if d.Err != nil {
+		err = d.Err
+	}
+	traceEvaluation(ctx, h.tracer, hc.Flag, hc.Subject, d.Variation, d.Reason, d.ErrorCode, err)
+}
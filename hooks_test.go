@@ -0,0 +1,169 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+type stageHook struct {
+	BaseHook
+	name   string
+	stages *[]string
+	before func(*HookContext) error
+	err    *EvaluationError
+}
+
+func (h *stageHook) Before(_ context.Context, hc *HookContext) error {
+	*h.stages = append(*h.stages, h.name+".before")
+	if h.before != nil {
+		return h.before(hc)
+	}
+	return nil
+}
+
+func (h *stageHook) After(context.Context, HookContext, EvaluationDetails) {
+	*h.stages = append(*h.stages, h.name+".after")
+}
+
+func (h *stageHook) Error(_ context.Context, _ HookContext, err *EvaluationError) {
+	h.err = err
+	*h.stages = append(*h.stages, h.name+".error")
+}
+
+func (h *stageHook) Finally(context.Context, HookContext, EvaluationDetails) {
+	*h.stages = append(*h.stages, h.name+".finally")
+}
+
+func TestHooks(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		before     func(*HookContext) error
+		want       int
+		wantCode   string
+		wantStages []string
+	}{
+		{
+			name: "success",
+			flag: numberFlagName,
+			want: 42,
+			wantStages: []string{
+				"a.before", "b.before", "a.after", "b.after", "a.finally", "b.finally",
+			},
+		},
+		{
+			name:     "evaluation error",
+			flag:     notExistsFlagName,
+			want:     1,
+			wantCode: errorCodeFlagNotFound,
+			wantStages: []string{
+				"a.before", "b.before", "a.error", "b.error", "a.finally", "b.finally",
+			},
+		},
+		{
+			name:     "before error skips evaluation",
+			flag:     numberFlagName,
+			before:   func(*HookContext) error { return errors.New("denied") },
+			want:     1,
+			wantCode: errorCodeGeneral,
+			wantStages: []string{
+				"a.before", "a.error", "b.error", "a.finally", "b.finally",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stages []string
+			a := &stageHook{name: "a", stages: &stages, before: tt.before}
+			b := &stageHook{name: "b", stages: &stages}
+			err := NewClient(Config{
+				Evaluator:           NewInMemory(map[string]any{numberFlagName: 42}),
+				Hooks:               []Hook{a, b},
+				DisableEnvOverrides: true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating client: %v", err)
+			}
+			defer Close()
+
+			got, err := GetInt(tt.flag, "user", 1)
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+			if diff := cmp.Diff(tt.wantStages, stages); diff != "" {
+				t.Errorf("unexpected hook stages (-want +got):\n%s", diff)
+			}
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var evalErr *EvaluationError
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("expected an *EvaluationError, got %v", err)
+			}
+			if evalErr.Code != tt.wantCode || b.err != evalErr {
+				t.Errorf("expected code %s passed to the error hook, got %s", tt.wantCode, evalErr.Code)
+			}
+		})
+	}
+}
+
+func TestHookReplacesSubject(t *testing.T) {
+	rec := NewRecorder(NewInMemory(map[string]any{isEnabledFlagName: true}))
+	var stages []string
+	err := NewClient(Config{
+		Evaluator: rec,
+		Hooks: []Hook{&stageHook{name: "a", stages: &stages, before: func(hc *HookContext) error {
+			hc.Subject = ffcontext.NewEvaluationContext(hc.Subject.GetKey() + "-eu")
+			return nil
+		}}},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if _, err = IsEnabled(isEnabledFlagName, "user", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	evals := rec.Evaluations()
+	if len(evals) != 1 {
+		t.Fatalf("expected 1 evaluation, got %d", len(evals))
+	}
+	if got := evals[0].Subject; got != "user-eu" {
+		t.Errorf("expected the subject set by the hook, got %s", got)
+	}
+}
+
+func TestWithHooks(t *testing.T) {
+	var stages []string
+	a := &stageHook{name: "a", stages: &stages}
+	mem := NewInMemory(map[string]any{numberFlagName: 42})
+	err := NewClient(Config{Evaluator: mem, Hooks: []Hook{a}, DisableEnvOverrides: true})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	e := WithHooks(mem)
+	res, err := e.IntVariationDetails(numberFlagName, ffcontext.NewEvaluationContext("user"), 1)
+	if err != nil || res.Value != 42 || res.Reason != reasonStatic {
+		t.Errorf("unexpected result %+v, %v", res, err)
+	}
+	_, err = e.IntVariationDetails(notExistsFlagName, ffcontext.NewEvaluationContext("user"), 1)
+	var evalErr *EvaluationError
+	if !errors.As(err, &evalErr) || evalErr.Code != errorCodeFlagNotFound {
+		t.Errorf("expected a flag not found *EvaluationError, got %v", err)
+	}
+	want := []string{"a.before", "a.after", "a.finally", "a.before", "a.error", "a.finally"}
+	if diff := cmp.Diff(want, stages); diff != "" {
+		t.Errorf("unexpected hook stages (-want +got):\n%s", diff)
+	}
+}
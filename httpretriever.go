@@ -0,0 +1,124 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FetchResult describes the last request an HTTPRetriever made.
+type FetchResult struct {
+	Time        time.Time
+	StatusCode  int
+	NotModified bool
+	ETag        string
+	Err         error
+}
+
+// StatusError is returned by an HTTPRetriever for a response that is neither
+// 200 nor a 304 it can serve the previous body for.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to fetch flags from %s: unexpected status %d", e.URL, e.StatusCode)
+}
+
+// HTTPRetriever fetches a flag file over HTTP with conditional requests,
+// an unchanged file costs a 304 and the previous body is served again.
+type HTTPRetriever struct {
+	URL    string
+	Header http.Header
+	// Timeout of each request. Default: 10s
+	Timeout time.Duration
+	// Client to send requests with. Default: http.DefaultClient
+	Client *http.Client
+
+	mu           sync.Mutex
+	body         []byte
+	etag         string
+	lastModified string
+	last         FetchResult
+}
+
+func (r *HTTPRetriever) Retrieve(ctx context.Context) ([]byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := 10 * time.Second
+	if r.Timeout > 0 {
+		timeout = r.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, res, err := r.fetch(ctx)
+	res.Time = time.Now()
+	res.Err = err
+	r.last = res
+	return b, err
+}
+
+func (r *HTTPRetriever) fetch(ctx context.Context) ([]byte, FetchResult, error) {
+	var res FetchResult
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return nil, res, fmt.Errorf("failed to create flag request: %w", err)
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	if r.body != nil {
+		if r.etag != "" {
+			req.Header.Set("If-None-Match", r.etag)
+		}
+		if r.lastModified != "" {
+			req.Header.Set("If-Modified-Since", r.lastModified)
+		}
+	}
+
+	client := http.DefaultClient
+	if r.Client != nil {
+		client = r.Client
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, res, fmt.Errorf("failed to fetch flags from %s: %w", r.URL, err)
+	}
+	defer resp.Body.Close()
+	res.StatusCode = resp.StatusCode
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && r.body != nil:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		res.NotModified = true
+		res.ETag = r.etag
+		return r.body, res, nil
+	case resp.StatusCode != http.StatusOK:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, res, &StatusError{URL: r.URL, StatusCode: resp.StatusCode}
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, res, fmt.Errorf("failed to read flags from %s: %w", r.URL, err)
+	}
+	r.body = b
+	r.etag = resp.Header.Get("ETag")
+	r.lastModified = resp.Header.Get("Last-Modified")
+	res.ETag = r.etag
+	return b, res, nil
+}
+
+// LastFetch is the result of the most recent request.
+func (r *HTTPRetriever) LastFetch() FetchResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
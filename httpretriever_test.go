@@ -0,0 +1,138 @@
+package flags
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+func TestHTTPRetriever(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		// validator is the response header the server validates with
+		validator string
+		value     string
+		request   string
+	}{
+		{name: "etag", validator: "ETag", value: `"v1"`, request: "If-None-Match"},
+		{name: "last modified", validator: "Last-Modified", value: "Mon, 01 Jan 2024 00:00:00 GMT", request: "If-Modified-Since"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var full, notModified atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if r.Header.Get(tt.request) == tt.value {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full.Add(1)
+				w.Header().Set(tt.validator, tt.value)
+				_, _ = w.Write([]byte(bootstrapFlags))
+			}))
+			defer srv.Close()
+
+			r := &HTTPRetriever{URL: srv.URL, Header: http.Header{"Authorization": {"Bearer token"}}}
+			for range 3 {
+				b, err := r.Retrieve(context.Background())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if string(b) != bootstrapFlags {
+					t.Fatalf("unexpected body %q", b)
+				}
+			}
+			if full.Load() != 1 || notModified.Load() != 2 {
+				t.Errorf("expected 1 full fetch and 2 not modified, got %d and %d", full.Load(), notModified.Load())
+			}
+			if last := r.LastFetch(); !last.NotModified || last.StatusCode != http.StatusNotModified {
+				t.Errorf("expected the last fetch to be not modified, got %+v", last)
+			}
+		})
+	}
+}
+
+func TestHTTPRetrieverErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		timeout time.Duration
+		status  int
+		kind    string
+	}{
+		{
+			name:    "server error",
+			handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			status:  http.StatusBadGateway,
+			kind:    "http_502",
+		},
+		{
+			name: "not modified without a body",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotModified)
+			},
+			status: http.StatusNotModified,
+			kind:   "http_304",
+		},
+		{
+			name: "timeout",
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+			timeout: 20 * time.Millisecond,
+			kind:    "timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			r := &HTTPRetriever{URL: srv.URL, Timeout: tt.timeout}
+			_, err := r.Retrieve(context.Background())
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if kind := retrievalErrorKind(err); kind != tt.kind {
+				t.Errorf("unexpected error kind: got %s want %s", kind, tt.kind)
+			}
+			if last := r.LastFetch(); last.Err == nil || last.StatusCode != tt.status {
+				t.Errorf("expected status %d and an error, got %+v", tt.status, last)
+			}
+		})
+	}
+}
+
+func TestNewClientHTTPRetriever(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(bootstrapFlags))
+	}))
+	defer srv.Close()
+
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&HTTPRetriever{URL: srv.URL}},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 1 {
+		t.Errorf("expected 1, got %d, %v", got, err)
+	}
+}
@@ -0,0 +1,281 @@
+package flags
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+// ReasonCached is reported for flags served from Config.CacheFile because
+// their retriever failed on startup.
+const ReasonCached = "CACHED"
+
+// cacheFile is the on disk format of the last known good flags, one
+// document per retriever index. Documents from a verifying retriever keep
+// their signature, which is checked again before they're served.
+type cacheFile struct {
+	Checksum   string         `json:"checksum"`
+	Saved      time.Time      `json:"saved"`
+	Format     string         `json:"format"`
+	Documents  map[int]string `json:"documents"`
+	Signatures map[int]string `json:"signatures,omitempty"`
+}
+
+func checksum(docs map[int]string) (string, error) {
+	b, err := json.Marshal(docs)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal documents: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readCacheFile returns the cached documents and their signatures.
+func readCacheFile(path, format string) (map[int]string, map[int]string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[int]string{}, map[int]string{}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read flag cache: %w", err)
+	}
+	var f cacheFile
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal flag cache: %w", err)
+	}
+	sum, err := checksum(f.Documents)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sum != f.Checksum {
+		return nil, nil, fmt.Errorf("flag cache %s is corrupt, checksum mismatch", path)
+	}
+	if f.Format != format {
+		return nil, nil, fmt.Errorf("flag cache %s has format %s, expected %s", path, f.Format, format)
+	}
+	if f.Signatures == nil {
+		f.Signatures = map[int]string{}
+	}
+	return f.Documents, f.Signatures, nil
+}
+
+// writeCacheFile replaces path atomically, a crash mid write leaves the
+// previous cache in place.
+func writeCacheFile(path, format string, docs, sigs map[int]string, now time.Time) error {
+	sum, err := checksum(docs)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(cacheFile{Checksum: sum, Saved: now, Format: format, Documents: docs, Signatures: sigs})
+	if err != nil {
+		return fmt.Errorf("failed to marshal flag cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create flag cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write flag cache: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync flag cache: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close flag cache: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace flag cache: %w", err)
+	}
+	return nil
+}
+
+// lastKnownGood persists every successful retrieval and serves the
+// persisted document for retrievers that fail before their first success.
+// A verifying retriever's document is only served if its signature still
+// verifies.
+type lastKnownGood struct {
+	path   string
+	format string
+	logger *limitedLogger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	docs      map[int]string
+	sigs      map[int]string
+	verifiers map[int]*verification
+	retrieved map[int]bool
+	cached    map[int][]string
+}
+
+func newLastKnownGood(path, format string, logger *limitedLogger) *lastKnownGood {
+	docs, sigs, err := readCacheFile(path, format)
+	if err != nil {
+		logger.logger.Warn("ignoring flag cache", "path", path, "error", err)
+		docs, sigs = map[int]string{}, map[int]string{}
+	}
+	return &lastKnownGood{
+		path:      path,
+		format:    format,
+		logger:    logger,
+		now:       time.Now,
+		docs:      docs,
+		sigs:      sigs,
+		verifiers: map[int]*verification{},
+		retrieved: map[int]bool{},
+		cached:    map[int][]string{},
+	}
+}
+
+func (c *lastKnownGood) wrap(retrievers []retriever.Retriever) []retriever.Retriever {
+	wrapped := make([]retriever.Retriever, 0, len(retrievers))
+	for i, r := range retrievers {
+		if v, ok := findLayer[*verification](r); ok {
+			c.verifiers[i] = v
+		}
+		wrapped = append(wrapped, wrapRetriever(r, func(next retrieveFunc) retrieveFunc {
+			return func(ctx context.Context) ([]byte, error) {
+				b, err := next(ctx)
+				if err != nil {
+					return c.fallback(i, err)
+				}
+				c.store(i, b)
+				return b, nil
+			}
+		}))
+	}
+	return wrapped
+}
+
+func (c *lastKnownGood) fallback(i int, err error) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, ok := c.docs[i]
+	if !ok || c.retrieved[i] {
+		return nil, err
+	}
+	if v, ok := c.verifiers[i]; ok {
+		if verr := v.verify([]byte(doc), []byte(c.sigs[i])); verr != nil {
+			c.logger.log(slog.LevelError, "cache/"+c.path, "not serving flags from cache, failed to verify them", "path", c.path, "retriever", i, "error", verr)
+			return nil, err
+		}
+	}
+	f, parseErr := parseFlagFile([]byte(doc), c.format)
+	if parseErr != nil {
+		return nil, err
+	}
+	c.cached[i] = slices.Collect(maps.Keys(f))
+	c.logger.log(slog.LevelWarn, "cache/"+c.path, "serving flags from cache", "path", c.path, "retriever", i, "error", err)
+	return []byte(doc), nil
+}
+
+func (c *lastKnownGood) store(i int, b []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retrieved[i] = true
+	delete(c.cached, i)
+	var sig string
+	if v, ok := c.verifiers[i]; ok {
+		sig = string(v.signature(b))
+	}
+	if c.docs[i] == string(b) && c.sigs[i] == sig {
+		return
+	}
+	c.docs[i] = string(b)
+	if sig != "" {
+		c.sigs[i] = sig
+	} else {
+		delete(c.sigs, i)
+	}
+	if err := writeCacheFile(c.path, c.format, c.docs, c.sigs, c.now()); err != nil {
+		c.logger.log(slog.LevelError, "cache/"+c.path, "failed to persist flag cache", "path", c.path, "error", err)
+	}
+}
+
+// isCached reports whether flag currently comes from the cache file.
+func (c *lastKnownGood) isCached(flag string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, keys := range c.cached {
+		if slices.Contains(keys, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedEvaluator reports ReasonCached for flags served from the cache.
+type cachedEvaluator struct {
+	Evaluator
+	cache *lastKnownGood
+}
+
+func (e cachedEvaluator) unwrap() Evaluator {
+	return e.Evaluator
+}
+
+func withCachedReason[T model.JSONType](c *lastKnownGood, flag string, res model.VariationResult[T], err error) (model.VariationResult[T], error) {
+	if err == nil && c.isCached(flag) {
+		res.Reason = ReasonCached
+	}
+	return res, err
+}
+
+func (e cachedEvaluator) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	res, err := e.Evaluator.BoolVariationDetails(flag, ctx, defaultValue)
+	return withCachedReason(e.cache, flag, res, err)
+}
+
+func (e cachedEvaluator) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	res, err := e.Evaluator.IntVariationDetails(flag, ctx, defaultValue)
+	return withCachedReason(e.cache, flag, res, err)
+}
+
+func (e cachedEvaluator) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	res, err := e.Evaluator.Float64VariationDetails(flag, ctx, defaultValue)
+	return withCachedReason(e.cache, flag, res, err)
+}
+
+func (e cachedEvaluator) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	res, err := e.Evaluator.StringVariationDetails(flag, ctx, defaultValue)
+	return withCachedReason(e.cache, flag, res, err)
+}
+
+func (e cachedEvaluator) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	res, err := e.Evaluator.JSONVariationDetails(flag, ctx, defaultValue)
+	return withCachedReason(e.cache, flag, res, err)
+}
+
+func (e cachedEvaluator) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	res, err := e.Evaluator.JSONArrayVariationDetails(flag, ctx, defaultValue)
+	return withCachedReason(e.cache, flag, res, err)
+}
+
+func (e cachedEvaluator) ForceRefresh() bool {
+	if r, ok := e.Evaluator.(refresher); ok {
+		return r.ForceRefresh()
+	}
+	return false
+}
+
+func (e cachedEvaluator) Close() {
+	if c, ok := e.Evaluator.(closer); ok {
+		c.Close()
+	}
+}
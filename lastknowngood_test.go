@@ -0,0 +1,108 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+func TestCacheFile(t *testing.T) {
+	t.Parallel()
+	docs := map[int]string{0: "a:\n  variations: {}\n"}
+	tests := []struct {
+		name      string
+		modify    func(string) string
+		format    string
+		want      map[int]string
+		expectErr bool
+	}{
+		{name: "round trip", format: "yaml", want: docs},
+		{
+			name:      "corrupt",
+			format:    "yaml",
+			modify:    func(s string) string { return strings.Replace(s, "variations", "variationz", 1) },
+			expectErr: true,
+		},
+		{name: "other format", format: "json", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "flags.cache")
+			if err := writeCacheFile(path, "yaml", docs, nil, time.Now()); err != nil {
+				t.Fatalf("unexpected error writing cache: %v", err)
+			}
+			if tt.modify != nil {
+				b, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err = os.WriteFile(path, []byte(tt.modify(string(b))), 0o644); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			got, _, err := readCacheFile(path, tt.format)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading cache: %v", err)
+			}
+			if got[0] != tt.want[0] {
+				t.Errorf("expected %q, got %q", tt.want[0], got[0])
+			}
+		})
+	}
+}
+
+func TestNewClientCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.cache")
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&fileretriever.Retriever{Path: yamlFlagFileName}},
+		CacheFile:           path,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	want, err := GetInt(numberFlagName, "user", 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	Close()
+	if _, err = os.Stat(path); err != nil {
+		t.Fatalf("expected the cache file to be written: %v", err)
+	}
+
+	err = NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{failingRetriever{}},
+		CacheFile:           path,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("expected the cache to be used, got %v", err)
+	}
+	defer Close()
+
+	rec := NewRecorder(Default())
+	got, err := GetInt(numberFlagName, "user", 7, rec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected the cached %d, got %d", want, got)
+	}
+	if evals := rec.Evaluations(); len(evals) != 1 || evals[0].Reason != ReasonCached {
+		t.Errorf("expected reason %s, got %+v", ReasonCached, evals)
+	}
+}
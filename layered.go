@@ -0,0 +1,91 @@
+package flags
+
+import (
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+const reasonOverride = "OVERRIDE"
+
+// Layered resolves each flag against its overrides in order and falls
+// through to the base when none of them define the flag. Values served by
+// an override are reported with the OVERRIDE reason.
+type Layered struct {
+	base      Evaluator
+	overrides []Evaluator
+}
+
+func NewLayered(base Evaluator, overrides ...Evaluator) *Layered {
+	return &Layered{base: base, overrides: overrides}
+}
+
+func (l *Layered) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return layered(l, func(e Evaluator) (model.VariationResult[bool], error) {
+		return e.BoolVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (l *Layered) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return layered(l, func(e Evaluator) (model.VariationResult[int], error) {
+		return e.IntVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (l *Layered) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return layered(l, func(e Evaluator) (model.VariationResult[float64], error) {
+		return e.Float64VariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (l *Layered) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return layered(l, func(e Evaluator) (model.VariationResult[string], error) {
+		return e.StringVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (l *Layered) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return layered(l, func(e Evaluator) (model.VariationResult[map[string]any], error) {
+		return e.JSONVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (l *Layered) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return layered(l, func(e Evaluator) (model.VariationResult[[]any], error) {
+		return e.JSONArrayVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (l *Layered) ForceRefresh() bool {
+	refreshed := false
+	for _, e := range append([]Evaluator{l.base}, l.overrides...) {
+		if r, ok := e.(refresher); ok {
+			refreshed = r.ForceRefresh() || refreshed
+		}
+	}
+	return refreshed
+}
+
+func (l *Layered) Close() {
+	for _, e := range append([]Evaluator{l.base}, l.overrides...) {
+		if c, ok := e.(closer); ok {
+			c.Close()
+		}
+	}
+}
+
+func layered[T model.JSONType](
+	l *Layered,
+	eval func(Evaluator) (model.VariationResult[T], error),
+) (model.VariationResult[T], error) {
+	for _, o := range l.overrides {
+		res, err := eval(o)
+		if res.ErrorCode == errorCodeFlagNotFound {
+			continue
+		}
+		if err == nil {
+			res.Reason = reasonOverride
+		}
+		return res, err
+	}
+	return eval(l.base)
+}
@@ -0,0 +1,103 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+func TestLayered(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "overrides.yaml")
+	err := os.WriteFile(path, []byte("ff-number: 42\nff-description: from file\n"), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error writing override file: %v", err)
+	}
+	file, err := NewOverrideFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading override file: %v", err)
+	}
+	memory := NewInMemory(map[string]any{descriptionFlagName: "from memory"})
+
+	l := NewLayered(setupClient(t, yamlFlagFileName), memory, file)
+
+	tests := []struct {
+		name     string
+		flag     string
+		expected any
+		reason   string
+	}{
+		{"first override wins", descriptionFlagName, "from memory", reasonOverride},
+		{"falls through to second override", numberFlagName, 42, reasonOverride},
+		{"falls through to base", floatFlagName, 3.14159, "STATIC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ffcontext.NewEvaluationContext("1")
+			var got any
+			var reason string
+			switch tt.expected.(type) {
+			case string:
+				res, err := l.StringVariationDetails(tt.flag, c, "")
+				if err != nil {
+					t.Fatalf("unexpected error getting flag value: %v", err)
+				}
+				got, reason = res.Value, res.Reason
+			case int:
+				res, err := l.IntVariationDetails(tt.flag, c, 0)
+				if err != nil {
+					t.Fatalf("unexpected error getting flag value: %v", err)
+				}
+				got, reason = res.Value, res.Reason
+			case float64:
+				res, err := l.Float64VariationDetails(tt.flag, c, 0)
+				if err != nil {
+					t.Fatalf("unexpected error getting flag value: %v", err)
+				}
+				got, reason = res.Value, res.Reason
+			}
+			if got != tt.expected {
+				t.Errorf("unexpected value: got %v want %v", got, tt.expected)
+			}
+			if reason != tt.reason {
+				t.Errorf("unexpected reason: got %s want %s", reason, tt.reason)
+			}
+		})
+	}
+
+	t.Run("override with the wrong type returns an error", func(t *testing.T) {
+		i, err := GetInt(descriptionFlagName, "1", 69, l)
+		if err == nil {
+			t.Errorf("expected error but got nil")
+		}
+		if i != 69 {
+			t.Errorf("unexpected int: got %d want %d", i, 69)
+		}
+	})
+
+	t.Run("override file is reloaded on refresh", func(t *testing.T) {
+		if err := os.WriteFile(path, []byte(`{"ff-number": 7}`), 0o644); err != nil {
+			t.Fatalf("unexpected error writing override file: %v", err)
+		}
+		Refresh(l)
+		i, err := GetInt(numberFlagName, "1", 69, l)
+		if err != nil {
+			t.Fatalf("unexpected error getting flag value: %v", err)
+		}
+		if i != 7 {
+			t.Errorf("unexpected int: got %d want %d", i, 7)
+		}
+	})
+}
+
+func TestOverrideFileMissing(t *testing.T) {
+	o, err := NewOverrideFile(filepath.Join(t.TempDir(), "nope.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error loading missing override file: %v", err)
+	}
+	if _, err = GetInt(numberFlagName, "1", 69, o); err == nil {
+		t.Errorf("expected error but got nil")
+	}
+}
@@ -0,0 +1,117 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const logDedupWindow = time.Minute
+
+// limitedLogger logs each distinct message at most once per window and
+// reports how many repeats were dropped in between, so a missing flag on a
+// hot path doesn't flood the logs.
+type limitedLogger struct {
+	logger *slog.Logger
+	window time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	last       map[string]time.Time
+	suppressed map[string]int
+	pruned     time.Time
+}
+
+func newLimitedLogger(logger *slog.Logger) *limitedLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &limitedLogger{
+		logger:     logger,
+		window:     logDedupWindow,
+		now:        time.Now,
+		last:       map[string]time.Time{},
+		suppressed: map[string]int{},
+	}
+}
+
+func (l *limitedLogger) log(level slog.Level, key, msg string, args ...any) {
+	if !l.logger.Enabled(context.Background(), level) {
+		return
+	}
+	l.mu.Lock()
+	now := l.now()
+	l.prune(now)
+	if last, ok := l.last[key]; ok && now.Sub(last) < l.window {
+		l.suppressed[key]++
+		l.mu.Unlock()
+		return
+	}
+	l.last[key] = now
+	if n := l.suppressed[key]; n > 0 {
+		args = append(args, "suppressed", n)
+		delete(l.suppressed, key)
+	}
+	l.mu.Unlock()
+	l.logger.Log(context.Background(), level, msg, args...)
+}
+
+// prune forgets keys idle for two windows, along with any suppressed count,
+// so keys that never repeat don't pile up. It runs at most once per window,
+// l.mu must be held.
+func (l *limitedLogger) prune(now time.Time) {
+	if now.Sub(l.pruned) < l.window {
+		return
+	}
+	l.pruned = now
+	for key, last := range l.last {
+		if now.Sub(last) >= 2*l.window {
+			delete(l.last, key)
+			delete(l.suppressed, key)
+		}
+	}
+}
+
+func (l *limitedLogger) evaluationError(flag, code string, err error) {
+	kind := errorKind(code)
+	msg := "flag evaluation failed, serving default"
+	if code == errorCodeTypeMismatch {
+		msg = "flag type mismatch, serving default"
+	}
+	l.log(slog.LevelWarn, "evaluation/"+flag+"/"+kind, msg, "flag", flag, "kind", kind, "error", err)
+}
+
+// refreshError is deduplicated by retriever and kind of error, the message
+// itself may hold request IDs, times or temp paths.
+func (l *limitedLogger) refreshError(retriever int, kind string, err error) {
+	key := "refresh/" + strconv.Itoa(retriever) + "/" + kind
+	l.log(slog.LevelError, key, "flag retriever failed", "retriever", retriever, "kind", kind, "error", err)
+}
+
+// retrievalErrorKind sorts retrieval errors into a few kinds.
+func retrievalErrorKind(err error) string {
+	var netErr net.Error
+	var statusErr *StatusError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, fs.ErrNotExist):
+		return "not_found"
+	case errors.Is(err, fs.ErrPermission):
+		return "permission"
+	case errors.Is(err, ErrUnsigned), errors.Is(err, ErrInvalidSignature):
+		return "signature"
+	case errors.As(err, &statusErr):
+		return "http_" + strconv.Itoa(statusErr.StatusCode)
+	case errors.As(err, &netErr):
+		return "network"
+	}
+	return "other"
+}
@@ -0,0 +1,119 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+func TestLimitedLoggerDedup(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := newLimitedLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	err := errors.New("flag missing is not present or disabled")
+	for range 5 {
+		l.evaluationError("missing", errorCodeFlagNotFound, err)
+	}
+	l.evaluationError("other", errorCodeFlagNotFound, err)
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Fatalf("expected 2 log lines inside the window, got %d:\n%s", got, buf.String())
+	}
+
+	buf.Reset()
+	now = now.Add(logDedupWindow)
+	l.evaluationError("missing", errorCodeFlagNotFound, err)
+	if !strings.Contains(buf.String(), "suppressed=4") {
+		t.Errorf("expected suppressed count after the window, got %s", buf.String())
+	}
+}
+
+func TestLoggerEvaluationErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewClient(Config{
+		Evaluator:           NewInMemory(map[string]any{numberFlagName: 42}),
+		Logger:              slog.New(slog.NewTextHandler(&buf, nil)),
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	tests := []struct {
+		name string
+		eval func() error
+		want string
+	}{
+		{
+			name: "type mismatch",
+			eval: func() error { _, err := GetString(numberFlagName, "user", "x"); return err },
+			want: "flag type mismatch, serving default",
+		},
+		{
+			name: "missing flag",
+			eval: func() error { _, err := GetInt(notExistsFlagName, "user", 1); return err },
+			want: "flag evaluation failed, serving default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			if err := tt.eval(); err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("expected log %q, got %s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestLoggerRetrieverFailures(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLimitedLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	rs := newRefreshTracker(1, "yaml", nil, l).wrap([]retriever.Retriever{failingRetriever{}})
+	for range 3 {
+		if _, err := rs[0].Retrieve(context.Background()); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if got := strings.Count(buf.String(), "flag retriever failed"); got != 1 {
+		t.Errorf("expected 1 retriever failure log, got %d:\n%s", got, buf.String())
+	}
+}
+
+func TestLimitedLoggerPrunes(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := newLimitedLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	// a request id in every message must not mean a key per message
+	for i := range 100 {
+		l.refreshError(0, retrievalErrorKind(fs.ErrNotExist), fmt.Errorf("request %d: %w", i, fs.ErrNotExist))
+	}
+	l.refreshError(0, "parse", errors.New("bad yaml"))
+	if got := strings.Count(buf.String(), "flag retriever failed"); got != 2 {
+		t.Errorf("expected 1 log per kind, got %d:\n%s", got, buf.String())
+	}
+
+	now = now.Add(2 * logDedupWindow)
+	l.evaluationError("other", errorCodeFlagNotFound, errors.New("missing"))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.last) != 1 || len(l.suppressed) != 0 {
+		t.Errorf("expected idle keys to be pruned, got %v and %v", l.last, l.suppressed)
+	}
+}
@@ -0,0 +1,129 @@
+package flags
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+const (
+	reasonStatic    = "STATIC"
+	reasonError     = "ERROR"
+	variationStatic = "static"
+	variationSDK    = "SdkDefault"
+
+	errorCodeFlagNotFound = "FLAG_NOT_FOUND"
+	errorCodeTypeMismatch = "TYPE_MISMATCH"
+	errorCodeGeneral      = "GENERAL"
+)
+
+// InMemory serves fixed values for every subject, which is useful in tests
+// and as an override layer. Values are matched to the getter the same way goff
+// does, JSON numbers may be read as ints or floats.
+type InMemory struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func NewInMemory(values map[string]any) *InMemory {
+	return &InMemory{values: maps.Clone(values)}
+}
+
+func (m *InMemory) Set(flag string, value any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.values == nil {
+		m.values = map[string]any{}
+	}
+	m.values[flag] = value
+}
+
+func (m *InMemory) Delete(flag string) {
+	m.mu.Lock()
+	delete(m.values, flag)
+	m.mu.Unlock()
+}
+
+func (m *InMemory) lookup(flag string) (any, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.values[flag]
+	return v, ok
+}
+
+func (m *InMemory) BoolVariationDetails(flag string, _ ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return staticVariation(m, flag, defaultValue)
+}
+
+func (m *InMemory) IntVariationDetails(flag string, _ ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return staticVariation(m, flag, defaultValue)
+}
+
+func (m *InMemory) Float64VariationDetails(flag string, _ ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return staticVariation(m, flag, defaultValue)
+}
+
+func (m *InMemory) StringVariationDetails(flag string, _ ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return staticVariation(m, flag, defaultValue)
+}
+
+func (m *InMemory) JSONVariationDetails(flag string, _ ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return staticVariation(m, flag, defaultValue)
+}
+
+func (m *InMemory) JSONArrayVariationDetails(flag string, _ ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return staticVariation(m, flag, defaultValue)
+}
+
+type lookuper interface {
+	lookup(flag string) (any, bool)
+}
+
+func staticVariation[T model.JSONType](l lookuper, flag string, defaultValue T) (model.VariationResult[T], error) {
+	raw, ok := l.lookup(flag)
+	if !ok {
+		return defaultResult(defaultValue, errorCodeFlagNotFound), fmt.Errorf("flag %v is not present or disabled", flag)
+	}
+	v, ok := coerce[T](raw)
+	if !ok {
+		return defaultResult(defaultValue, errorCodeTypeMismatch), fmt.Errorf("wrong variation used for flag %v", flag)
+	}
+	return model.VariationResult[T]{
+		Value:         v,
+		VariationType: variationStatic,
+		Reason:        reasonStatic,
+		Cacheable:     true,
+	}, nil
+}
+
+func defaultResult[T model.JSONType](defaultValue T, code string) model.VariationResult[T] {
+	return model.VariationResult[T]{
+		Value:         defaultValue,
+		VariationType: variationSDK,
+		Failed:        true,
+		Reason:        reasonError,
+		ErrorCode:     code,
+	}
+}
+
+func coerce[T model.JSONType](raw any) (T, bool) {
+	if v, ok := raw.(T); ok {
+		return v, true
+	}
+	var zero T
+	switch any(zero).(type) {
+	case int:
+		// assuming whole floats are ints, same as json numbers elsewhere
+		if f, ok := raw.(float64); ok && f == float64(int(f)) {
+			return any(int(f)).(T), true
+		}
+	case float64:
+		if i, ok := raw.(int); ok {
+			return any(float64(i)).(T), true
+		}
+	}
+	return zero, false
+}
@@ -0,0 +1,308 @@
+package flags
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+// Metrics receives counts from evaluations and retriever refreshes,
+// Registry is the built-in implementation.
+type Metrics interface {
+	ObserveEvaluation(flag, variation, reason string, latency time.Duration)
+	IncError(kind string)
+	ObserveRefresh(success bool)
+	SetFlagCount(n int)
+}
+
+var latencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+type evaluationKey struct {
+	flag, variation, reason string
+}
+
+// Registry keeps metrics in memory and serves them in the Prometheus text
+// exposition format.
+type Registry struct {
+	now func() time.Time
+
+	mu             sync.Mutex
+	evaluations    map[evaluationKey]uint64
+	errors         map[string]uint64
+	refreshes      map[string]uint64
+	lastRefresh    time.Time
+	flagCount      int
+	latencyCounts  []uint64
+	latencySum     float64
+	latencyObserve uint64
+	retries        uint64
+	retrying       int
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		now:           time.Now,
+		evaluations:   map[evaluationKey]uint64{},
+		errors:        map[string]uint64{},
+		refreshes:     map[string]uint64{},
+		latencyCounts: make([]uint64, len(latencyBuckets)),
+	}
+}
+
+func (r *Registry) ObserveEvaluation(flag, variation, reason string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evaluations[evaluationKey{flag, variation, reason}]++
+	s := latency.Seconds()
+	for i, le := range latencyBuckets {
+		if s <= le {
+			r.latencyCounts[i]++
+		}
+	}
+	r.latencySum += s
+	r.latencyObserve++
+}
+
+func (r *Registry) IncError(kind string) {
+	r.mu.Lock()
+	r.errors[kind]++
+	r.mu.Unlock()
+}
+
+func (r *Registry) ObserveRefresh(success bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !success {
+		r.refreshes["failure"]++
+		return
+	}
+	r.refreshes["success"]++
+	r.lastRefresh = r.now()
+}
+
+func (r *Registry) SetFlagCount(n int) {
+	r.mu.Lock()
+	r.flagCount = n
+	r.mu.Unlock()
+}
+
+func (r *Registry) IncRetry() {
+	r.mu.Lock()
+	r.retries++
+	r.mu.Unlock()
+}
+
+func (r *Registry) SetRetrying(n int) {
+	r.mu.Lock()
+	r.retrying = n
+	r.mu.Unlock()
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP flags_evaluations_total Flag evaluations by flag, variation and reason.")
+	fmt.Fprintln(w, "# TYPE flags_evaluations_total counter")
+	keys := slices.SortedFunc(maps.Keys(r.evaluations), func(a, b evaluationKey) int {
+		return strings.Compare(a.flag+a.variation+a.reason, b.flag+b.variation+b.reason)
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "flags_evaluations_total{flag=%q,variation=%q,reason=%q} %d\n", k.flag, k.variation, k.reason, r.evaluations[k])
+	}
+
+	fmt.Fprintln(w, "# HELP flags_errors_total Evaluation errors by kind.")
+	fmt.Fprintln(w, "# TYPE flags_errors_total counter")
+	for _, k := range slices.Sorted(maps.Keys(r.errors)) {
+		fmt.Fprintf(w, "flags_errors_total{kind=%q} %d\n", k, r.errors[k])
+	}
+
+	fmt.Fprintln(w, "# HELP flags_refreshes_total Refreshes by result, a refresh fails if any retriever does.")
+	fmt.Fprintln(w, "# TYPE flags_refreshes_total counter")
+	for _, k := range []string{"success", "failure"} {
+		fmt.Fprintf(w, "flags_refreshes_total{result=%q} %d\n", k, r.refreshes[k])
+	}
+
+	fmt.Fprintln(w, "# HELP flags_retries_total Retrieval retries.")
+	fmt.Fprintln(w, "# TYPE flags_retries_total counter")
+	fmt.Fprintf(w, "flags_retries_total %d\n", r.retries)
+
+	fmt.Fprintln(w, "# HELP flags_retrying Number of retrievers currently backing off.")
+	fmt.Fprintln(w, "# TYPE flags_retrying gauge")
+	fmt.Fprintf(w, "flags_retrying %d\n", r.retrying)
+
+	fmt.Fprintln(w, "# HELP flags_cache_age_seconds Seconds since the last successful refresh, -1 before the first.")
+	fmt.Fprintln(w, "# TYPE flags_cache_age_seconds gauge")
+	age := -1.
+	if !r.lastRefresh.IsZero() {
+		age = r.now().Sub(r.lastRefresh).Seconds()
+	}
+	fmt.Fprintf(w, "flags_cache_age_seconds %g\n", age)
+
+	fmt.Fprintln(w, "# HELP flags_loaded Number of flags loaded by the retrievers.")
+	fmt.Fprintln(w, "# TYPE flags_loaded gauge")
+	fmt.Fprintf(w, "flags_loaded %d\n", r.flagCount)
+
+	fmt.Fprintln(w, "# HELP flags_evaluation_duration_seconds Evaluation latency.")
+	fmt.Fprintln(w, "# TYPE flags_evaluation_duration_seconds histogram")
+	for i, le := range latencyBuckets {
+		fmt.Fprintf(w, "flags_evaluation_duration_seconds_bucket{le=\"%g\"} %d\n", le, r.latencyCounts[i])
+	}
+	fmt.Fprintf(w, "flags_evaluation_duration_seconds_bucket{le=\"+Inf\"} %d\n", r.latencyObserve)
+	fmt.Fprintf(w, "flags_evaluation_duration_seconds_sum %g\n", r.latencySum)
+	fmt.Fprintf(w, "flags_evaluation_duration_seconds_count %d\n", r.latencyObserve)
+}
+
+// refreshTracker reports every refresh to the metrics and logs failed
+// retrievals. goff calls every retriever on each refresh and only updates its
+// cache if all of them succeed, so a refresh only counts as successful once
+// every retriever has. It counts the flags across all retrievers, a flag
+// defined by more than one retriever is only counted once, same as goff's
+// cache.
+type refreshTracker struct {
+	metrics Metrics
+	logger  *limitedLogger
+	format  string
+	n       int
+
+	mu    sync.Mutex
+	flags map[int][]string
+	sums  map[int][sha256.Size]byte
+	// cycle has the result of each retriever in the refresh in progress
+	cycle map[int]bool
+	last  time.Time
+}
+
+func newRefreshTracker(n int, format string, m Metrics, l *limitedLogger) *refreshTracker {
+	return &refreshTracker{
+		metrics: m,
+		logger:  l,
+		format:  format,
+		n:       n,
+		flags:   map[int][]string{},
+		sums:    map[int][sha256.Size]byte{},
+		cycle:   map[int]bool{},
+	}
+}
+
+func (t *refreshTracker) wrap(retrievers []retriever.Retriever) []retriever.Retriever {
+	wrapped := make([]retriever.Retriever, 0, len(retrievers))
+	for i, r := range retrievers {
+		wrapped = append(wrapped, wrapRetriever(r, func(next retrieveFunc) retrieveFunc {
+			return func(ctx context.Context) ([]byte, error) {
+				b, err := next(ctx)
+				t.observe(i, b, err)
+				return b, err
+			}
+		}))
+	}
+	return wrapped
+}
+
+// lastRefresh is when every retriever last succeeded in the same refresh,
+// zero if they never have. Unlike goff's refresh date it doesn't move while
+// the flags are served from the cache file or the bootstrap.
+func (t *refreshTracker) lastRefresh() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last
+}
+
+func (t *refreshTracker) observe(i int, b []byte, err error) {
+	var f flagFile
+	var kind string
+	// e.g. an HTTPRetriever's 304, no need to parse it again
+	unchanged := err == nil && t.unchanged(i, b)
+	switch {
+	case err != nil:
+		kind = retrievalErrorKind(err)
+	case !unchanged:
+		f, err = parseFlagFile(b, t.format)
+		kind = "parse"
+	}
+	if err != nil {
+		if t.logger != nil {
+			t.logger.refreshError(i, kind, err)
+		}
+		t.report(i, false)
+		return
+	}
+	if !unchanged {
+		if t.logger != nil {
+			t.logger.logger.Debug("flags retrieved", "retriever", i, "flags", len(f))
+		}
+		t.mu.Lock()
+		t.sums[i] = sha256.Sum256(b)
+		t.flags[i] = slices.Collect(maps.Keys(f))
+		t.mu.Unlock()
+	}
+	t.report(i, true)
+}
+
+// report records the result of retriever i and settles the refresh once
+// every retriever reported. A retriever reporting twice means a new refresh
+// started without the others, e.g. goff skips retrievers that aren't ready,
+// so the previous one is settled with what it has.
+func (t *refreshTracker) report(i int, ok bool) {
+	t.mu.Lock()
+	var settled []bool
+	if _, again := t.cycle[i]; again {
+		settled = append(settled, t.settle())
+	}
+	t.cycle[i] = ok
+	if len(t.cycle) == t.n {
+		settled = append(settled, t.settle())
+	}
+	var count int
+	if slices.Contains(settled, true) {
+		count = t.flagCount()
+		t.last = time.Now()
+	}
+	t.mu.Unlock()
+
+	if t.metrics == nil {
+		return
+	}
+	for _, success := range settled {
+		t.metrics.ObserveRefresh(success)
+		if success {
+			t.metrics.SetFlagCount(count)
+		}
+	}
+}
+
+// settle ends the refresh in progress, t.mu must be held.
+func (t *refreshTracker) settle() bool {
+	success := true
+	for _, ok := range t.cycle {
+		success = success && ok
+	}
+	clear(t.cycle)
+	return success
+}
+
+func (t *refreshTracker) flagCount() int {
+	seen := map[string]struct{}{}
+	for _, keys := range t.flags {
+		for _, k := range keys {
+			seen[k] = struct{}{}
+		}
+	}
+	return len(seen)
+}
+
+func (t *refreshTracker) unchanged(i int, b []byte) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sum, ok := t.sums[i]
+	return ok && sum == sha256.Sum256(b)
+}
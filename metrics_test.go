@@ -0,0 +1,121 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+type failingRetriever struct{}
+
+func (failingRetriever) Retrieve(context.Context) ([]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestRegistryEvaluations(t *testing.T) {
+	reg := NewRegistry()
+	err := NewClient(Config{
+		Evaluator:           NewInMemory(map[string]any{numberFlagName: 42}),
+		Metrics:             reg,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	for range 2 {
+		if _, err = GetInt(numberFlagName, "1", 69); err != nil {
+			t.Fatalf("unexpected error getting flag value: %v", err)
+		}
+	}
+	if _, err = GetInt(notExistsFlagName, "1", 69); err == nil {
+		t.Fatalf("expected error but got nil")
+	}
+
+	body := scrape(t, reg)
+	for _, want := range []string{
+		`flags_evaluations_total{flag="ff-number",variation="static",reason="STATIC"} 2`,
+		`flags_evaluations_total{flag="not-exists",variation="SdkDefault",reason="ERROR"} 1`,
+		`flags_errors_total{kind="flag_not_found"} 1`,
+		`flags_evaluation_duration_seconds_count 3`,
+		`flags_evaluation_duration_seconds_bucket{le="+Inf"} 3`,
+		`flags_cache_age_seconds -1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %s, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestRegistryRefreshes(t *testing.T) {
+	tests := []struct {
+		name       string
+		retrievers []retriever.Retriever
+		want       []string
+	}{
+		{
+			name: "every retriever succeeds",
+			retrievers: []retriever.Retriever{
+				&fileretriever.Retriever{Path: yamlFlagFileName},
+				&fileretriever.Retriever{Path: yamlFlagFileName},
+			},
+			want: []string{
+				`flags_refreshes_total{result="success"} 3`,
+				`flags_refreshes_total{result="failure"} 0`,
+				`flags_cache_age_seconds 30`,
+				`flags_loaded 9`,
+			},
+		},
+		{
+			// goff never updates its cache, so neither does the age
+			name: "one retriever keeps failing",
+			retrievers: []retriever.Retriever{
+				&fileretriever.Retriever{Path: yamlFlagFileName},
+				failingRetriever{},
+			},
+			want: []string{
+				`flags_refreshes_total{result="success"} 0`,
+				`flags_refreshes_total{result="failure"} 3`,
+				`flags_cache_age_seconds -1`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			reg := NewRegistry()
+			now := time.Now()
+			reg.now = func() time.Time { return now }
+
+			retrievers := newRefreshTracker(len(tt.retrievers), "yaml", reg, nil).wrap(tt.retrievers)
+			for range 3 {
+				for _, r := range retrievers {
+					_, _ = r.Retrieve(context.Background())
+				}
+			}
+			now = now.Add(30 * time.Second)
+
+			body := scrape(t, reg)
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("expected metrics to contain %s, got:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OverlayRetriever reads a base flag file, e.g. flags.goff.yaml, and deep
+// merges the environment's overlay, flags.staging.goff.yaml, on top. Maps
+// are merged key by key, anything else in the overlay replaces the base
+// value and a null removes it. A missing overlay serves the base as is.
+type OverlayRetriever struct {
+	Path string
+	// Environment picks the overlay. Default: Config.Environment
+	Environment string
+	// Format the resolved flag set is returned in. Default: the extension
+	// of Path
+	Format string
+}
+
+func (r *OverlayRetriever) Retrieve(_ context.Context) ([]byte, error) {
+	return ResolveOverlay(r.Path, r.Environment, r.Format)
+}
+
+// OverlayPath is the overlay of the base flag file for env, the environment
+// goes before ".goff" or, failing that, the extension.
+func OverlayPath(base, env string) string {
+	dir, name := filepath.Split(base)
+	i := strings.Index(name, ".goff.")
+	if i < 0 {
+		i = len(name) - len(filepath.Ext(name))
+	}
+	return dir + name[:i] + "." + env + name[i:]
+}
+
+// ResolveOverlay returns the base flag file at path merged with the
+// overlay for env, in format or the format of path when empty.
+func ResolveOverlay(path, env, format string) ([]byte, error) {
+	base, err := readFlagFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base flag file: %w", err)
+	}
+	if base == nil {
+		base = flagFile{}
+	}
+	if env != "" {
+		overlay, err := readFlagFile(OverlayPath(path, env))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s overlay: %w", env, err)
+		}
+		for flag, fields := range overlay {
+			if fields == nil {
+				delete(base, flag)
+				continue
+			}
+			base[flag] = deepMerge(base[flag], fields)
+		}
+	}
+	if format == "" {
+		format = fileFormatOf(path)
+	}
+	return marshalFlagFile(base, format)
+}
+
+func deepMerge(base, overlay map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(overlay))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overlay {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		b, bOk := merged[k].(map[string]any)
+		o, oOk := v.(map[string]any)
+		if bOk && oOk {
+			merged[k] = deepMerge(b, o)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,129 @@
+package flags
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+const overlayBase = `
+ff-number:
+  variations:
+    low: 1
+    high: 100
+  targeting:
+    - query: key eq "beta"
+      variation: high
+  defaultRule:
+    variation: low
+ff-string:
+  variations:
+    a: a
+  defaultRule:
+    variation: a
+`
+
+const overlayStaging = `
+ff-number:
+  variations:
+    high: 50
+  targeting: []
+  defaultRule:
+    variation: high
+ff-string: ~
+`
+
+func TestOverlayPath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		base string
+		want string
+	}{
+		{base: "flags.goff.yaml", want: "flags.staging.goff.yaml"},
+		{base: "/etc/flags/flags.goff.json", want: "/etc/flags/flags.staging.goff.json"},
+		{base: "flags.yaml", want: "flags.staging.yaml"},
+	}
+	for _, tt := range tests {
+		if got := OverlayPath(tt.base, "staging"); got != tt.want {
+			t.Errorf("OverlayPath(%s): expected %s, got %s", tt.base, tt.want, got)
+		}
+	}
+}
+
+func TestResolveOverlay(t *testing.T) {
+	t.Parallel()
+	dir := writeFlagDir(t, map[string]string{
+		"flags.goff.yaml":         overlayBase,
+		"flags.staging.goff.yaml": overlayStaging,
+	})
+	tests := []struct {
+		name string
+		env  string
+		want flagFile
+	}{
+		{
+			name: "staging",
+			env:  "staging",
+			want: flagFile{
+				"ff-number": {
+					"variations":  map[string]any{"low": 1, "high": 50},
+					"targeting":   []any{},
+					"defaultRule": map[string]any{"variation": "high"},
+				},
+			},
+		},
+		{
+			name: "no overlay for env",
+			env:  "prod",
+			want: mustParse(t, overlayBase),
+		},
+		{
+			name: "no env",
+			want: mustParse(t, overlayBase),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := ResolveOverlay(dir+"/flags.goff.yaml", tt.env, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, mustParse(t, string(b))); diff != "" {
+				t.Errorf("unexpected resolved flags (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func mustParse(t *testing.T, s string) flagFile {
+	t.Helper()
+	f, err := parseFlagFile([]byte(s), "yaml")
+	if err != nil {
+		t.Fatalf("unexpected error parsing flags: %v", err)
+	}
+	return f
+}
+
+func TestNewClientOverlay(t *testing.T) {
+	dir := writeFlagDir(t, map[string]string{
+		"flags.goff.yaml":         overlayBase,
+		"flags.staging.goff.yaml": overlayStaging,
+	})
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&OverlayRetriever{Path: dir + "/flags.goff.yaml"}},
+		Environment:         "staging",
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 50 {
+		t.Errorf("expected the staging value 50, got %d, %v", got, err)
+	}
+}
@@ -0,0 +1,98 @@
+package flags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+	"gopkg.in/yaml.v3"
+)
+
+// OverrideFile serves values from a flat `flag: value` YAML or JSON file,
+// typically a developer's local overrides layered over the shared retrievers.
+// A missing file is treated as having no overrides.
+type OverrideFile struct {
+	path string
+
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func NewOverrideFile(path string) (*OverrideFile, error) {
+	o := &OverrideFile{path: path}
+	if err := o.Reload(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *OverrideFile) Reload() error {
+	b, err := os.ReadFile(o.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		o.set(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read override file %s: %w", o.path, err)
+	}
+
+	var values map[string]any
+	if strings.EqualFold(filepath.Ext(o.path), ".json") {
+		err = json.Unmarshal(b, &values)
+	} else {
+		err = yaml.Unmarshal(b, &values)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse override file %s: %w", o.path, err)
+	}
+	o.set(values)
+	return nil
+}
+
+func (o *OverrideFile) set(values map[string]any) {
+	o.mu.Lock()
+	o.values = values
+	o.mu.Unlock()
+}
+
+func (o *OverrideFile) lookup(flag string) (any, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	v, ok := o.values[flag]
+	return v, ok
+}
+
+func (o *OverrideFile) ForceRefresh() bool {
+	return o.Reload() == nil
+}
+
+func (o *OverrideFile) BoolVariationDetails(flag string, _ ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return staticVariation(o, flag, defaultValue)
+}
+
+func (o *OverrideFile) IntVariationDetails(flag string, _ ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return staticVariation(o, flag, defaultValue)
+}
+
+func (o *OverrideFile) Float64VariationDetails(flag string, _ ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return staticVariation(o, flag, defaultValue)
+}
+
+func (o *OverrideFile) StringVariationDetails(flag string, _ ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return staticVariation(o, flag, defaultValue)
+}
+
+func (o *OverrideFile) JSONVariationDetails(flag string, _ ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return staticVariation(o, flag, defaultValue)
+}
+
+func (o *OverrideFile) JSONArrayVariationDetails(flag string, _ ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return staticVariation(o, flag, defaultValue)
+}
@@ -0,0 +1,171 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+// ReasonNotReady is the reason getters report while an async NewClient is
+// still loading flags, they serve the default value until it is ready.
+const ReasonNotReady = "NOT_READY"
+
+const errorCodeNotReady = "PROVIDER_NOT_READY"
+
+var ErrNotReady = errors.New("flags are not ready")
+
+// startup tracks the state of one NewClient call.
+type startup struct {
+	ready   chan struct{}
+	failed  chan struct{}
+	once    sync.Once
+	err     error
+	retrier *retrier
+	stale   *staleness
+	watcher *FileWatcher
+}
+
+func newStartup() *startup {
+	return &startup{ready: make(chan struct{}), failed: make(chan struct{})}
+}
+
+func (s *startup) markReady() {
+	s.once.Do(func() { close(s.ready) })
+}
+
+func (s *startup) fail(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.failed)
+	})
+}
+
+// begin makes s the startup Ready and WaitReady report on, an install for
+// an older startup is ignored.
+func begin(s *startup) {
+	defaultMu.Lock()
+	prev := current
+	current = s
+	defaultMu.Unlock()
+	prev.stop()
+}
+
+// stop releases what the startup holds on to beyond the evaluator.
+func (s *startup) stop() {
+	if s.watcher != nil {
+		s.watcher.Close()
+	}
+}
+
+func currentStartup() *startup {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return current
+}
+
+// Ready is closed once the flags from the last NewClient are live.
+func Ready() <-chan struct{} {
+	return currentStartup().ready
+}
+
+// WaitReady blocks until the flags are live, the async init failed or ctx
+// is done.
+func WaitReady(ctx context.Context) error {
+	s := currentStartup()
+	select {
+	case <-s.ready:
+		return nil
+	case <-s.failed:
+		return fmt.Errorf("failed to init flags: %w", s.err)
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for flags: %w", ctx.Err())
+	}
+}
+
+// ReadinessHandler responds 200 once the flags are live and 503 before, for
+// use as an orchestrator readiness probe.
+func ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s := currentStartup()
+		status, body := http.StatusServiceUnavailable, map[string]string{"status": "not ready"}
+		select {
+		case <-s.ready:
+			status, body = http.StatusOK, map[string]string{"status": "ready"}
+		case <-s.failed:
+			body["error"] = s.err.Error()
+		default:
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+// notReady serves every flag's default while an async NewClient loads.
+type notReady struct{}
+
+func notReadyResult[T model.JSONType](defaultValue T) (model.VariationResult[T], error) {
+	res := defaultResult(defaultValue, errorCodeNotReady)
+	res.Reason = ReasonNotReady
+	return res, ErrNotReady
+}
+
+func (notReady) BoolVariationDetails(_ string, _ ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return notReadyResult(defaultValue)
+}
+
+func (notReady) IntVariationDetails(_ string, _ ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return notReadyResult(defaultValue)
+}
+
+func (notReady) Float64VariationDetails(_ string, _ ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return notReadyResult(defaultValue)
+}
+
+func (notReady) StringVariationDetails(_ string, _ ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return notReadyResult(defaultValue)
+}
+
+func (notReady) JSONVariationDetails(_ string, _ ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return notReadyResult(defaultValue)
+}
+
+func (notReady) JSONArrayVariationDetails(_ string, _ ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return notReadyResult(defaultValue)
+}
+
+// HealthHandler reports the health of the flags loaded by NewClient as JSON,
+// "retrying" while any retriever is backing off and "degraded" once the
+// flags are older than Config.MaxStaleness. It always responds 200 as the
+// client still serves flags, use ReadinessHandler to gate traffic.
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s := currentStartup()
+		body := struct {
+			Status      string        `json:"status"`
+			Retrying    []RetryStatus `json:"retrying,omitempty"`
+			Stale       bool          `json:"stale,omitempty"`
+			LastRefresh *time.Time    `json:"last_refresh,omitempty"`
+		}{Status: "ok", Retrying: s.retrier.status()}
+		stale, last := s.stale.stale()
+		if !last.IsZero() {
+			body.LastRefresh = &last
+		}
+		switch {
+		case stale:
+			body.Status = "degraded"
+			body.Stale = true
+		case len(body.Retrying) > 0:
+			body.Status = "retrying"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
@@ -0,0 +1,115 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+// blockingRetriever holds back the flags until release is closed.
+type blockingRetriever struct {
+	retriever.Retriever
+	release chan struct{}
+}
+
+func (r blockingRetriever) Retrieve(ctx context.Context) ([]byte, error) {
+	<-r.release
+	return r.Retriever.Retrieve(ctx)
+}
+
+func probe(t *testing.T) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return rec.Code
+}
+
+func TestAsyncInit(t *testing.T) {
+	release := make(chan struct{})
+	err := NewClient(Config{
+		PollingInterval: 10 * time.Second,
+		Retrievers: []retriever.Retriever{blockingRetriever{
+			Retriever: &fileretriever.Retriever{Path: yamlFlagFileName},
+			release:   release,
+		}},
+		AsyncInit:           true,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	var reason string
+	rec := NewRecorder(Default())
+	got, err := GetInt(numberFlagName, "user", 7, rec)
+	if !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected ErrNotReady, got %v", err)
+	}
+	if got != 7 {
+		t.Errorf("expected the default 7 before ready, got %d", got)
+	}
+	if evals := rec.Evaluations(); len(evals) == 1 {
+		reason = evals[0].Reason
+	}
+	if reason != ReasonNotReady {
+		t.Errorf("expected reason %s, got %s", ReasonNotReady, reason)
+	}
+	if code := probe(t); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before ready, got %d", code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err = WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to time out, got %v", err)
+	}
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = WaitReady(ctx); err != nil {
+		t.Fatalf("unexpected error waiting: %v", err)
+	}
+	select {
+	case <-Ready():
+	default:
+		t.Errorf("expected Ready to be closed")
+	}
+	if code := probe(t); code != http.StatusOK {
+		t.Errorf("expected 200 once ready, got %d", code)
+	}
+	if got, err = GetInt(numberFlagName, "user", 7); err != nil || got == 7 {
+		t.Errorf("expected the loaded flag, got %d, %v", got, err)
+	}
+}
+
+func TestAsyncInitFailure(t *testing.T) {
+	err := NewClient(Config{
+		PollingInterval: 10 * time.Second,
+		Retrievers: []retriever.Retriever{
+			&fileretriever.Retriever{Path: "non-existent.goff.yaml"},
+		},
+		AsyncInit:           true,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = WaitReady(ctx); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the init error, got %v", err)
+	}
+	if code := probe(t); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after a failed init, got %d", code)
+	}
+}
@@ -0,0 +1,87 @@
+package flags
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+type Evaluation struct {
+	Flag      string
+	Subject   string
+	Value     any
+	Variation string
+	Reason    string
+	Err       error
+}
+
+// Recorder wraps another Evaluator and keeps every evaluation it sees,
+// mainly so tests can assert which flags a code path read.
+type Recorder struct {
+	next Evaluator
+
+	mu          sync.Mutex
+	evaluations []Evaluation
+}
+
+func NewRecorder(next Evaluator) *Recorder {
+	return &Recorder{next: next}
+}
+
+func (r *Recorder) Evaluations() []Evaluation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.evaluations)
+}
+
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.evaluations = nil
+	r.mu.Unlock()
+}
+
+func (r *Recorder) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	res, err := r.next.BoolVariationDetails(flag, ctx, defaultValue)
+	return record(r, flag, ctx, res, err)
+}
+
+func (r *Recorder) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	res, err := r.next.IntVariationDetails(flag, ctx, defaultValue)
+	return record(r, flag, ctx, res, err)
+}
+
+func (r *Recorder) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	res, err := r.next.Float64VariationDetails(flag, ctx, defaultValue)
+	return record(r, flag, ctx, res, err)
+}
+
+func (r *Recorder) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	res, err := r.next.StringVariationDetails(flag, ctx, defaultValue)
+	return record(r, flag, ctx, res, err)
+}
+
+func (r *Recorder) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	res, err := r.next.JSONVariationDetails(flag, ctx, defaultValue)
+	return record(r, flag, ctx, res, err)
+}
+
+func (r *Recorder) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	res, err := r.next.JSONArrayVariationDetails(flag, ctx, defaultValue)
+	return record(r, flag, ctx, res, err)
+}
+
+func record[T model.JSONType](r *Recorder, flag string, ctx ffcontext.Context, res model.VariationResult[T], err error) (model.VariationResult[T], error) {
+	r.mu.Lock()
+	r.evaluations = append(r.evaluations, Evaluation{
+		Flag:      flag,
+		Subject:   ctx.GetKey(),
+		Value:     res.Value,
+		Variation: res.VariationType,
+		Reason:    res.Reason,
+		Err:       err,
+	})
+	r.mu.Unlock()
+	return res, err
+}
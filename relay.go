@@ -0,0 +1,206 @@
+package flags
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+type RelayConfig struct {
+	Retrievers []retriever.Retriever
+	// FileFormat of the retrievers and the served document. Default: yaml
+	FileFormat string
+	// PollingInterval between retrievals. Default: 60s
+	PollingInterval time.Duration
+	// Logger reports failed refreshes. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// relayDoc is the flag document as served, precompressed.
+type relayDoc struct {
+	raw      []byte
+	gzipped  []byte
+	etag     string
+	modified time.Time
+}
+
+// Relay pulls flags through its retrievers and serves the flag document to
+// other instances, which fetch it with an HTTPRetriever. A single
+// retriever's document is served byte for byte, several are merged the way
+// goff merges them. Responses carry an ETag and are gzipped when the client
+// accepts it.
+type Relay struct {
+	retrievers []retriever.Retriever
+	format     string
+	logger     *slog.Logger
+	shutdown   func()
+
+	mu  sync.RWMutex
+	doc *relayDoc
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewRelay inits the retrievers that need it and loads the flags once,
+// failing if either fails, then refreshes them every PollingInterval until
+// Close.
+func NewRelay(cfg RelayConfig) (*Relay, error) {
+	if len(cfg.Retrievers) == 0 {
+		return nil, errors.New("relay expects at least 1 retriever")
+	}
+	interval := time.Minute
+	if cfg.PollingInterval > 0 {
+		interval = cfg.PollingInterval
+	}
+	logger := slog.Default()
+	if cfg.Logger != nil {
+		logger = cfg.Logger
+	}
+	r := &Relay{
+		retrievers: cfg.Retrievers,
+		format:     fileFormat(Config{FileFormat: cfg.FileFormat}),
+		logger:     logger,
+		done:       make(chan struct{}),
+	}
+	shutdown, err := initRetrievers(context.Background(), r.retrievers, logger)
+	if err != nil {
+		return nil, err
+	}
+	r.shutdown = shutdown
+	if err = r.Refresh(context.Background()); err != nil {
+		shutdown()
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.poll(interval)
+	return r, nil
+}
+
+func (r *Relay) poll(interval time.Duration) {
+	defer r.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+			if err := r.Refresh(context.Background()); err != nil {
+				r.logger.Error("failed to refresh relayed flags, serving the previous ones", "error", err)
+			}
+		}
+	}
+}
+
+// Refresh retrieves the flags now, the served document only changes, along
+// with its ETag, if they did.
+func (r *Relay) Refresh(ctx context.Context) error {
+	b, err := r.retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	r.mu.RLock()
+	unchanged := r.doc != nil && r.doc.etag == etag
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err = w.Write(b); err != nil {
+		return fmt.Errorf("failed to compress flags: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to compress flags: %w", err)
+	}
+	r.mu.Lock()
+	r.doc = &relayDoc{raw: b, gzipped: gz.Bytes(), etag: etag, modified: time.Now()}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Relay) retrieve(ctx context.Context) ([]byte, error) {
+	if len(r.retrievers) == 1 {
+		b, err := r.retrievers[0].Retrieve(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve flags: %w", err)
+		}
+		if _, err = parseFlagFile(b, r.format); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	f, err := loadFlagFile(ctx, r.retrievers, r.format)
+	if err != nil {
+		return nil, err
+	}
+	return marshalFlagFile(f, r.format)
+}
+
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.mu.RLock()
+	doc := r.doc
+	r.mu.RUnlock()
+
+	body, etag := doc.raw, doc.etag
+	w.Header().Set("Vary", "Accept-Encoding")
+	if acceptsGzip(req) {
+		// the encoded representation needs its own ETag
+		body, etag = doc.gzipped, strings.TrimSuffix(etag, `"`)+`-gzip"`
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", relayContentType(r.format))
+	http.ServeContent(w, req, "", doc.modified, bytes.NewReader(body))
+}
+
+// Close stops refreshing and shuts down the retrievers that were inited.
+func (r *Relay) Close() {
+	r.once.Do(func() {
+		close(r.done)
+		r.wg.Wait()
+		r.shutdown()
+	})
+}
+
+func acceptsGzip(req *http.Request) bool {
+	for _, enc := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		enc, q, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.TrimSpace(enc) == "gzip" && strings.TrimSpace(q) != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+func relayContentType(format string) string {
+	switch format {
+	case "json":
+		return "application/json"
+	case "toml":
+		return "application/toml"
+	}
+	return "application/yaml"
+}
@@ -0,0 +1,203 @@
+package flags
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+func TestRelay(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "flags.goff.yaml")
+	writeFile(t, path, bootstrapFlags)
+
+	relay, err := NewRelay(RelayConfig{
+		Retrievers:      []retriever.Retriever{&fileretriever.Retriever{Path: path}},
+		PollingInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer relay.Close()
+
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/flags", nil)
+		req.Header = header
+		w := httptest.NewRecorder()
+		relay.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(http.Header{})
+	if w.Code != http.StatusOK || w.Body.String() != bootstrapFlags {
+		t.Fatalf("expected the raw flag file, got %d %q", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("unexpected content type %s", ct)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected an ETag")
+	}
+
+	if w = get(http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("expected not modified, got %d", w.Code)
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		w := get(http.Header{"Accept-Encoding": {"br, gzip"}})
+		if w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("expected a gzipped response, got %v", w.Header())
+		}
+		if w.Header().Get("ETag") == etag {
+			t.Errorf("expected the gzipped response to have its own ETag")
+		}
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := io.ReadAll(zr)
+		if err != nil || string(b) != bootstrapFlags {
+			t.Errorf("unexpected body %q, %v", b, err)
+		}
+		if w := get(http.Header{"Accept-Encoding": {"gzip;q=0"}}); w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("expected gzip to be refused with q=0")
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		relay.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/flags", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("unexpected status %d", w.Code)
+		}
+	})
+
+	// a refresh without changes keeps the ETag, a failed one keeps the
+	// document, a change replaces both
+	if err = relay.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := get(http.Header{}).Header().Get("ETag"); got != etag {
+		t.Errorf("expected an unchanged ETag, got %s want %s", got, etag)
+	}
+	writeFile(t, path, "ff-number: [")
+	if err = relay.Refresh(context.Background()); err == nil {
+		t.Errorf("expected an error refreshing an invalid flag file")
+	}
+	if w = get(http.Header{}); w.Body.String() != bootstrapFlags {
+		t.Errorf("expected the previous flags after a failed refresh, got %q", w.Body)
+	}
+	writeFile(t, path, yamlFlag("ff-number", 2))
+	if err = relay.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w = get(http.Header{"If-None-Match": {etag}}); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("expected the changed flags with a new ETag, got %d %s", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestRelayMergesRetrievers(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.yaml"), filepath.Join(dir, "second.yaml")
+	writeFile(t, first, bootstrapFlags)
+	writeFile(t, second, yamlFlag("ff-number", 2))
+
+	relay, err := NewRelay(RelayConfig{
+		Retrievers: []retriever.Retriever{
+			&fileretriever.Retriever{Path: first},
+			&fileretriever.Retriever{Path: second},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer relay.Close()
+
+	w := httptest.NewRecorder()
+	relay.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/flags", nil))
+	f, err := parseFlagFile(w.Body.Bytes(), "yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := f["bootstrap-only"]; !ok {
+		t.Errorf("expected flags from the first retriever, got %v", f)
+	}
+	if v, _ := f.variations("ff-number"); v["A"] != 2 {
+		t.Errorf("expected the second retriever to win, got %v", v)
+	}
+
+	if _, err = NewRelay(RelayConfig{}); err == nil {
+		t.Errorf("expected an error without retrievers")
+	}
+	if _, err = NewRelay(RelayConfig{Retrievers: []retriever.Retriever{failingRetriever{}}}); err == nil {
+		t.Errorf("expected an error when the first retrieval fails")
+	}
+}
+
+func TestRelayHTTPRetriever(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "flags.goff.yaml")
+	writeFile(t, path, bootstrapFlags)
+	relay, err := NewRelay(RelayConfig{
+		Retrievers:      []retriever.Retriever{&fileretriever.Retriever{Path: path}},
+		PollingInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer relay.Close()
+	srv := httptest.NewServer(relay)
+	defer srv.Close()
+
+	// the default transport asks for gzip and decompresses it
+	r := &HTTPRetriever{URL: srv.URL}
+	for range 2 {
+		b, err := r.Retrieve(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(b, []byte(bootstrapFlags)) {
+			t.Fatalf("unexpected body %q", b)
+		}
+	}
+	if !r.LastFetch().NotModified {
+		t.Errorf("expected the second fetch to be not modified, got %+v", r.LastFetch())
+	}
+}
+
+func yamlFlag(name string, value int) string {
+	return fmt.Sprintf("%s:\n  variations:\n    A: %d\n  defaultRule:\n    variation: A\n", name, value)
+}
+
+func TestRelayInitializableRetriever(t *testing.T) {
+	t.Parallel()
+	r := &initRetriever{Retriever: &fileretriever.Retriever{Path: yamlFlagFileName}}
+	relay, err := NewRelay(RelayConfig{
+		Retrievers:      []retriever.Retriever{r},
+		PollingInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	relay.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/flags", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status %d", w.Code)
+	}
+	relay.Close()
+	if r.Status() == retriever.RetrieverReady {
+		t.Errorf("expected Close to shut the retriever down")
+	}
+}
@@ -0,0 +1,256 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	URL              string
+	HTTPClient       *http.Client
+	Timeout          time.Duration
+	CacheTTL         time.Duration
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+type cacheEntry struct {
+	resp    EvaluateResponse
+	expires time.Time
+}
+
+type Client struct {
+	url     string
+	http    *http.Client
+	timeout time.Duration
+	ttl     time.Duration
+	now     func() time.Time
+
+	breaker *breaker
+	group   group
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("remote client expects a url")
+	}
+	httpClient := http.DefaultClient
+	if cfg.HTTPClient != nil {
+		httpClient = cfg.HTTPClient
+	}
+	timeout := 2 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = cfg.Timeout
+	}
+	threshold := 5
+	if cfg.FailureThreshold > 0 {
+		threshold = cfg.FailureThreshold
+	}
+	cooldown := 30 * time.Second
+	if cfg.Cooldown > 0 {
+		cooldown = cfg.Cooldown
+	}
+	return &Client{
+		url:     strings.TrimSuffix(cfg.URL, "/"),
+		http:    httpClient,
+		timeout: timeout,
+		ttl:     cfg.CacheTTL,
+		now:     time.Now,
+		breaker: &breaker{threshold: threshold, cooldown: cooldown, now: time.Now},
+		cache:   map[string]cacheEntry{},
+	}, nil
+}
+
+func (c *Client) IsEnabled(flag, userID string, defaultValue bool) (bool, error) {
+	return evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeBool}, defaultValue)
+}
+
+func (c *Client) IsEnabledByID(flag, userID, id, lookup string, defaultValue bool) (bool, error) {
+	rawID, err := json.Marshal(id)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to marshal id: %w", err)
+	}
+	return evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeBool, Lookup: lookup, ID: rawID}, defaultValue)
+}
+
+func (c *Client) GetInt(flag, userID string, defaultValue int) (int, error) {
+	return evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeInt}, defaultValue)
+}
+
+func (c *Client) GetFloat(flag, userID string, defaultValue float64) (float64, error) {
+	return evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeFloat}, defaultValue)
+}
+
+func (c *Client) GetString(flag, userID string, defaultValue string) (string, error) {
+	return evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeString}, defaultValue)
+}
+
+func (c *Client) GetTime(flag, userID, layout string, defaultValue time.Time) (time.Time, error) {
+	s, err := evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeTime, Layout: layout}, defaultValue.Format(layout))
+	if err != nil {
+		return defaultValue, err
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to parse time %s into layout %s: %w", s, layout, err)
+	}
+	return t, nil
+}
+
+func (c *Client) GetJSONMap(flag, userID string, defaultValue map[string]any) (map[string]any, error) {
+	return evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeJSON}, defaultValue)
+}
+
+func GetJSONStruct[T any](c *Client, flag, userID string, defaultValue T) (T, error) {
+	defaultBytes, err := json.Marshal(defaultValue)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to marshal default value: %w", err)
+	}
+
+	var defaultMap map[string]any
+	if err = json.Unmarshal(defaultBytes, &defaultMap); err != nil {
+		return defaultValue, fmt.Errorf("failed to unmarshal default value to map: %w", err)
+	}
+
+	j, err := c.GetJSONMap(flag, userID, defaultMap)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	result, err := json.Marshal(j)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to marshal result to target: %w", err)
+	}
+
+	var v T
+	if err = json.Unmarshal(result, &v); err != nil {
+		return defaultValue, fmt.Errorf("failed to unmarshal flag to target: %w", err)
+	}
+	return v, nil
+}
+
+func IsEnabledByIDList[T comparable](c *Client, flag, userID string, lookup T, defaultValue bool) (bool, error) {
+	rawID, err := json.Marshal(lookup)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to marshal id: %w", err)
+	}
+	return evaluate(c, EvaluateRequest{Flag: flag, UserID: userID, Type: TypeIDList, ID: rawID}, defaultValue)
+}
+
+func evaluate[T any](c *Client, req EvaluateRequest, defaultValue T) (T, error) {
+	if req.UserID == "" {
+		req.UserID = "anonymous"
+	}
+	def, err := json.Marshal(defaultValue)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to marshal default value: %w", err)
+	}
+	req.Default = def
+
+	resp, err := c.do(req)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	var v T
+	if err = json.Unmarshal(resp.Value, &v); err != nil {
+		return defaultValue, fmt.Errorf("failed to unmarshal flag %s: %w", req.Flag, err)
+	}
+	if resp.Error != "" {
+		return v, &Error{Flag: req.Flag, Code: resp.Code, Message: resp.Error}
+	}
+	return v, nil
+}
+
+func (c *Client) do(req EvaluateRequest) (EvaluateResponse, error) {
+	key, err := json.Marshal(req)
+	if err != nil {
+		return EvaluateResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if resp, ok := c.cached(string(key)); ok {
+		return resp, nil
+	}
+
+	return c.group.do(string(key), func() (EvaluateResponse, error) {
+		if !c.breaker.allow() {
+			return EvaluateResponse{}, fmt.Errorf("failed to evaluate flag %s: %w", req.Flag, ErrCircuitOpen)
+		}
+		resp, err := c.post(key)
+		if err != nil {
+			c.breaker.failure()
+			return EvaluateResponse{}, fmt.Errorf("failed to evaluate flag %s: %w", req.Flag, err)
+		}
+		c.breaker.success()
+		c.store(string(key), resp)
+		return resp, nil
+	})
+}
+
+func (c *Client) post(body []byte) (EvaluateResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/v1/evaluate", bytes.NewReader(body))
+	if err != nil {
+		return EvaluateResponse{}, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	res, err := c.http.Do(r)
+	if err != nil {
+		return EvaluateResponse{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return EvaluateResponse{}, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	var resp EvaluateResponse
+	if err = json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return EvaluateResponse{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) cached(key string) (EvaluateResponse, bool) {
+	if c.ttl <= 0 {
+		return EvaluateResponse{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.cache[key]
+	if !ok {
+		return EvaluateResponse{}, false
+	}
+	if c.now().After(e.expires) {
+		delete(c.cache, key)
+		return EvaluateResponse{}, false
+	}
+	return e.resp, true
+}
+
+func (c *Client) store(key string, resp EvaluateResponse) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.cache[key] = cacheEntry{resp: resp, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+}
+
+func (c *Client) Purge() {
+	c.mu.Lock()
+	clear(c.cache)
+	c.mu.Unlock()
+}
@@ -0,0 +1,215 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+var values = map[string]any{
+	"is-enabled":     true,
+	"ff-number":      9081,
+	"ff-float":       3.14159,
+	"ff-description": "Something about chocolate eggs",
+	"cr-start":       "2025-07-18T22:37:22.176Z",
+	"ff-json":        map[string]any{"p50": 40, "p75": 50},
+}
+
+func fakeServer(t *testing.T, calls *atomic.Int32, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(delay)
+		var req EvaluateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := EvaluateResponse{Flag: req.Flag, Value: req.Default}
+		if v, ok := values[req.Flag]; ok {
+			resp.Value, _ = json.Marshal(v)
+			resp.Variant = "default"
+			resp.Reason = "STATIC"
+		} else {
+			resp.Error = "flag " + req.Flag + " is not present or disabled"
+			resp.Code = CodeFlagNotFound
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func setupClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	return c
+}
+
+func TestGetters(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeServer(t, &calls, 0)
+	c := setupClient(t, Config{URL: srv.URL})
+
+	b, err := c.IsEnabled("is-enabled", "1", false)
+	if err != nil || !b {
+		t.Errorf("unexpected bool: got %t, %v want %t", b, err, true)
+	}
+	i, err := c.GetInt("ff-number", "1", 69)
+	if err != nil || i != 9081 {
+		t.Errorf("unexpected int: got %d, %v want %d", i, err, 9081)
+	}
+	f, err := c.GetFloat("ff-float", "", 1.11)
+	if err != nil || f != 3.14159 {
+		t.Errorf("unexpected float: got %f, %v want %f", f, err, 3.14159)
+	}
+	s, err := c.GetString("ff-description", "1", "hello")
+	if err != nil || s != "Something about chocolate eggs" {
+		t.Errorf("unexpected string: got %s, %v want %s", s, err, "Something about chocolate eggs")
+	}
+	ti, err := c.GetTime("cr-start", "1", time.RFC3339, time.Time{})
+	if err != nil || ti.Year() != 2025 {
+		t.Errorf("unexpected time: got %s, %v", ti, err)
+	}
+
+	type ResponseTimes struct {
+		P50 int `json:"p50"`
+		P75 int `json:"p75"`
+	}
+	j, err := GetJSONStruct(c, "ff-json", "1", ResponseTimes{})
+	if err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if diff := cmp.Diff(j, ResponseTimes{P50: 40, P75: 50}); diff != "" {
+		t.Errorf("unexpected struct (-got +want)\n%s", diff)
+	}
+}
+
+func TestVariationDetails(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeServer(t, &calls, 0)
+	c := setupClient(t, Config{URL: srv.URL})
+
+	res, err := c.IntVariationDetails("ff-number", ffcontext.NewEvaluationContext("1"), 69)
+	if err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if res.Value != 9081 || res.Reason != "STATIC" || res.VariationType != "default" || res.Failed {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	res, err = c.IntVariationDetails("not-exists", ffcontext.NewEvaluationContext("1"), 69)
+	if !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected ErrFlagNotFound but got %v", err)
+	}
+	if res.Value != 69 || !res.Failed || res.ErrorCode != CodeFlagNotFound {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestMissingFlagReturnsDefault(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeServer(t, &calls, 0)
+	c := setupClient(t, Config{URL: srv.URL})
+
+	i, err := c.GetInt("not-exists", "1", 69)
+	if !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected ErrFlagNotFound but got %v", err)
+	}
+	var remoteErr *Error
+	if !errors.As(err, &remoteErr) || remoteErr.Flag != "not-exists" {
+		t.Errorf("expected *Error for not-exists but got %v", err)
+	}
+	if i != 69 {
+		t.Errorf("unexpected int: got %d want %d", i, 69)
+	}
+}
+
+func TestCache(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeServer(t, &calls, 0)
+	c := setupClient(t, Config{URL: srv.URL, CacheTTL: time.Minute})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	for range 3 {
+		if _, err := c.GetInt("ff-number", "1", 69); err != nil {
+			t.Fatalf("unexpected error getting flag value: %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("unexpected calls: got %d want %d", got, 1)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := c.GetInt("ff-number", "1", 69); err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected expired entry to be fetched again: got %d calls want %d", got, 2)
+	}
+}
+
+func TestCoalescing(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeServer(t, &calls, 50*time.Millisecond)
+	c := setupClient(t, Config{URL: srv.URL})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetInt("ff-number", "1", 69); err != nil {
+				t.Errorf("unexpected error getting flag value: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := calls.Load(); got >= 10 {
+		t.Errorf("expected concurrent requests to be coalesced, got %d calls", got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	c := setupClient(t, Config{URL: srv.URL, FailureThreshold: 2, Cooldown: time.Minute})
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+
+	for range 2 {
+		if v, err := c.GetInt("ff-number", "1", 69); err == nil || v != 69 {
+			t.Fatalf("expected error and default but got %d, %v", v, err)
+		}
+	}
+	if _, err := c.GetInt("ff-number", "1", 69); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen but got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("unexpected calls: got %d want %d", got, 2)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := c.GetInt("ff-number", "1", 69); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected probe request after cooldown but got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("unexpected calls: got %d want %d", got, 3)
+	}
+}
@@ -0,0 +1,89 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+// The *VariationDetails methods make Client a flags.Evaluator, so it can be
+// set as flags.Config.Evaluator and the getters evaluate remotely.
+
+func (c *Client) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return variationDetails(c, flag, ctx, TypeBool, defaultValue)
+}
+
+func (c *Client) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return variationDetails(c, flag, ctx, TypeInt, defaultValue)
+}
+
+func (c *Client) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return variationDetails(c, flag, ctx, TypeFloat, defaultValue)
+}
+
+func (c *Client) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return variationDetails(c, flag, ctx, TypeString, defaultValue)
+}
+
+func (c *Client) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return variationDetails(c, flag, ctx, TypeJSON, defaultValue)
+}
+
+func (c *Client) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return variationDetails(c, flag, ctx, TypeJSONArray, defaultValue)
+}
+
+// ForceRefresh drops the local cache so the next evaluations go to the server.
+func (c *Client) ForceRefresh() bool {
+	c.Purge()
+	return true
+}
+
+func variationDetails[T model.JSONType](
+	c *Client,
+	flag string,
+	ctx ffcontext.Context,
+	typ string,
+	defaultValue T,
+) (model.VariationResult[T], error) {
+	res := model.VariationResult[T]{
+		Value:         defaultValue,
+		VariationType: "SdkDefault",
+		Failed:        true,
+		Reason:        "ERROR",
+		ErrorCode:     CodeGeneral,
+	}
+
+	def, err := json.Marshal(defaultValue)
+	if err != nil {
+		return res, fmt.Errorf("failed to marshal default value: %w", err)
+	}
+	resp, err := c.do(EvaluateRequest{
+		Flag:    flag,
+		UserID:  ctx.GetKey(),
+		Type:    typ,
+		Default: def,
+		Custom:  ctx.GetCustom(),
+	})
+	if err != nil {
+		return res, err
+	}
+
+	var v T
+	if err = json.Unmarshal(resp.Value, &v); err != nil {
+		return res, fmt.Errorf("failed to unmarshal flag %s: %w", flag, err)
+	}
+	res.Value = v
+	res.Reason = resp.Reason
+	if resp.Error != "" {
+		res.ErrorCode = resp.Code
+		res.ErrorDetails = resp.Error
+		return res, &Error{Flag: flag, Code: resp.Code, Message: resp.Error}
+	}
+	res.VariationType = resp.Variant
+	res.Failed = false
+	res.ErrorCode = ""
+	return res, nil
+}
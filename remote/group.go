@@ -0,0 +1,39 @@
+package remote
+
+import "sync"
+
+type call struct {
+	wg   sync.WaitGroup
+	resp EvaluateResponse
+	err  error
+}
+
+// group coalesces concurrent requests for the same key into a single round trip.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+func (g *group) do(key string, fn func() (EvaluateResponse, error)) (EvaluateResponse, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.resp, c.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.resp, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	return c.resp, c.err
+}
@@ -0,0 +1,76 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	TypeBool      = "bool"
+	TypeInt       = "int"
+	TypeFloat     = "float"
+	TypeString    = "string"
+	TypeTime      = "time"
+	TypeJSON      = "json"
+	TypeJSONArray = "json_array"
+	TypeIDList    = "id_list"
+)
+
+const (
+	CodeFlagNotFound = "FLAG_NOT_FOUND"
+	CodeTypeMismatch = "TYPE_MISMATCH"
+	CodeGeneral      = "GENERAL"
+)
+
+var (
+	ErrFlagNotFound = errors.New("flag not found")
+	ErrTypeMismatch = errors.New("flag type mismatch")
+	ErrCircuitOpen  = errors.New("circuit breaker is open")
+)
+
+type EvaluateRequest struct {
+	Flag    string          `json:"flag"`
+	UserID  string          `json:"userID"`
+	Type    string          `json:"type"`
+	Default json.RawMessage `json:"default"`
+	Layout  string          `json:"layout,omitempty"`
+	Lookup  string          `json:"lookup,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Custom  map[string]any  `json:"custom,omitempty"`
+}
+
+type EvaluateResponse struct {
+	Flag    string          `json:"flag"`
+	Value   json.RawMessage `json:"value"`
+	Variant string          `json:"variant,omitempty"`
+	Reason  string          `json:"reason,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Code    string          `json:"code,omitempty"`
+}
+
+type EvaluateAllRequest struct {
+	UserID string `json:"userID"`
+}
+
+// Error is returned when the server evaluated the flag but fell back to the
+// default, it unwraps to ErrFlagNotFound or ErrTypeMismatch where it can.
+type Error struct {
+	Flag    string
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("failed to evaluate flag %s (%s): %s", e.Flag, e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch e.Code {
+	case CodeFlagNotFound:
+		return ErrFlagNotFound
+	case CodeTypeMismatch:
+		return ErrTypeMismatch
+	}
+	return nil
+}
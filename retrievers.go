@@ -0,0 +1,144 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/utils/fflog"
+)
+
+type retrieveFunc func(ctx context.Context) ([]byte, error)
+
+// wrappedRetriever replaces Retrieve on a retriever while still exposing
+// Init, Shutdown and Status when it has them, so goff manages it the same way.
+type wrappedRetriever struct {
+	retriever.Retriever
+	retrieve retrieveFunc
+	// layer is the state behind retrieve, if any, see findLayer
+	layer any
+}
+
+func (w *wrappedRetriever) Retrieve(ctx context.Context) ([]byte, error) {
+	return w.retrieve(ctx)
+}
+
+type wrappedInitializable struct {
+	*wrappedRetriever
+	r retriever.InitializableRetriever
+}
+
+func (w *wrappedInitializable) Init(ctx context.Context, logger *fflog.FFLogger) error {
+	return w.r.Init(ctx, logger)
+}
+
+func (w *wrappedInitializable) Shutdown(ctx context.Context) error {
+	return w.r.Shutdown(ctx)
+}
+
+func (w *wrappedInitializable) Status() retriever.Status {
+	return w.r.Status()
+}
+
+type wrappedLegacy struct {
+	*wrappedRetriever
+	r retriever.InitializableRetrieverLegacy
+}
+
+func (w *wrappedLegacy) Init(ctx context.Context, logger *log.Logger) error {
+	return w.r.Init(ctx, logger)
+}
+
+func (w *wrappedLegacy) Shutdown(ctx context.Context) error {
+	return w.r.Shutdown(ctx)
+}
+
+func (w *wrappedLegacy) Status() retriever.Status {
+	return w.r.Status()
+}
+
+// unwrapRetriever returns the retriever underneath any wrapRetriever calls.
+func unwrapRetriever(r retriever.Retriever) retriever.Retriever {
+	for {
+		switch w := r.(type) {
+		case *wrappedRetriever:
+			r = w.Retriever
+		case *wrappedInitializable:
+			r = w.Retriever
+		case *wrappedLegacy:
+			r = w.Retriever
+		default:
+			return r
+		}
+	}
+}
+
+// findLayer returns the first layer of type T wrapped around r.
+func findLayer[T any](r retriever.Retriever) (T, bool) {
+	for {
+		var w *wrappedRetriever
+		switch v := r.(type) {
+		case *wrappedRetriever:
+			w = v
+		case *wrappedInitializable:
+			w = v.wrappedRetriever
+		case *wrappedLegacy:
+			w = v.wrappedRetriever
+		default:
+			var zero T
+			return zero, false
+		}
+		if l, ok := w.layer.(T); ok {
+			return l, true
+		}
+		r = w.Retriever
+	}
+}
+
+func wrapRetriever(r retriever.Retriever, retrieve func(next retrieveFunc) retrieveFunc) retriever.Retriever {
+	return wrapLayer(r, nil, retrieve)
+}
+
+// wrapLayer is wrapRetriever keeping layer for findLayer.
+func wrapLayer(r retriever.Retriever, layer any, retrieve func(next retrieveFunc) retrieveFunc) retriever.Retriever {
+	w := &wrappedRetriever{Retriever: r, retrieve: retrieve(r.Retrieve), layer: layer}
+	switch v := r.(type) {
+	case retriever.InitializableRetriever:
+		return &wrappedInitializable{wrappedRetriever: w, r: v}
+	case retriever.InitializableRetrieverLegacy:
+		return &wrappedLegacy{wrappedRetriever: w, r: v}
+	}
+	return w
+}
+
+// initRetrievers runs Init on the retrievers that need it, as goff does
+// before it retrieves. The returned func shuts them down again.
+func initRetrievers(ctx context.Context, retrievers []retriever.Retriever, logger *slog.Logger) (func(), error) {
+	var started []retriever.CommonInitializableRetriever
+	shutdown := func() {
+		for _, r := range started {
+			if err := r.Shutdown(ctx); err != nil {
+				logger.Warn("failed to shut down flag retriever", "error", err)
+			}
+		}
+	}
+	for i, r := range retrievers {
+		var err error
+		switch v := r.(type) {
+		case retriever.InitializableRetriever:
+			err = v.Init(ctx, &fflog.FFLogger{LeveledLogger: logger})
+		case retriever.InitializableRetrieverLegacy:
+			err = v.Init(ctx, slog.NewLogLogger(logger.Handler(), slog.LevelError))
+		default:
+			continue
+		}
+		if err != nil {
+			shutdown()
+			return nil, fmt.Errorf("failed to init retriever %d: %w", i, err)
+		}
+		started = append(started, r.(retriever.CommonInitializableRetriever))
+	}
+	return shutdown, nil
+}
@@ -0,0 +1,179 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+// RetryPolicy retries failed retrievals, on init and on every refresh, with
+// exponential backoff. The zero value uses the defaults.
+type RetryPolicy struct {
+	// MaxAttempts per retrieval, including the first. Default: 5
+	MaxAttempts int
+	// InitialBackoff before the first retry. Default: 500ms
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff between attempts. Default: 30s
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after every attempt. Default: 2
+	Multiplier float64
+	// Jitter randomises each backoff by up to this fraction. Default: 0.2
+	Jitter float64
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 5
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 500 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Jitter <= 0 || p.Jitter > 1 {
+		p.Jitter = 0.2
+	}
+	return p
+}
+
+// backoff is the wait after the given failed attempt, starting at 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	d = math.Min(d, float64(p.MaxBackoff))
+	d *= 1 + p.Jitter*(2*rand.Float64()-1)
+	return time.Duration(d)
+}
+
+// RetryMetrics is optionally implemented by Metrics to track retries,
+// Registry implements it.
+type RetryMetrics interface {
+	IncRetry()
+	// SetRetrying is the number of retrievers currently backing off
+	SetRetrying(n int)
+}
+
+// RetryStatus is a retriever's retry state, see HealthHandler.
+type RetryStatus struct {
+	Retriever   int       `json:"retriever"`
+	Attempt     int       `json:"attempt"`
+	LastError   string    `json:"last_error"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// retrier retries retrievals for one NewClient and keeps the state of the
+// retrievers that are currently backing off.
+type retrier struct {
+	policy  RetryPolicy
+	metrics Metrics
+	logger  *limitedLogger
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+
+	mu       sync.Mutex
+	retrying map[int]RetryStatus
+}
+
+func newRetrier(p RetryPolicy, m Metrics, l *limitedLogger) *retrier {
+	return &retrier{
+		policy:   p.withDefaults(),
+		metrics:  m,
+		logger:   l,
+		now:      time.Now,
+		sleep:    sleep,
+		retrying: map[int]RetryStatus{},
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (r *retrier) wrap(retrievers []retriever.Retriever) []retriever.Retriever {
+	wrapped := make([]retriever.Retriever, 0, len(retrievers))
+	for i, ret := range retrievers {
+		wrapped = append(wrapped, wrapRetriever(ret, func(next retrieveFunc) retrieveFunc {
+			return func(ctx context.Context) ([]byte, error) {
+				return r.retrieve(ctx, i, next)
+			}
+		}))
+	}
+	return wrapped
+}
+
+func (r *retrier) retrieve(ctx context.Context, i int, next retrieveFunc) ([]byte, error) {
+	defer r.done(i)
+	if ctx == nil {
+		// goff passes its Config.Context, which we don't set
+		ctx = context.Background()
+	}
+	for attempt := 1; ; attempt++ {
+		b, err := next(ctx)
+		if err == nil || attempt == r.policy.MaxAttempts {
+			return b, err
+		}
+		d := r.policy.backoff(attempt)
+		r.backingOff(RetryStatus{
+			Retriever:   i,
+			Attempt:     attempt,
+			LastError:   err.Error(),
+			NextAttempt: r.now().Add(d),
+		})
+		r.logger.logger.Warn("retrying flag retriever", "retriever", i, "attempt", attempt, "backoff", d, "error", err)
+		if sleepErr := r.sleep(ctx, d); sleepErr != nil {
+			return nil, fmt.Errorf("failed to retry retriever: %w", err)
+		}
+	}
+}
+
+func (r *retrier) backingOff(s RetryStatus) {
+	r.mu.Lock()
+	r.retrying[s.Retriever] = s
+	n := len(r.retrying)
+	r.mu.Unlock()
+	if m, ok := r.metrics.(RetryMetrics); ok {
+		m.IncRetry()
+		m.SetRetrying(n)
+	}
+}
+
+func (r *retrier) done(i int) {
+	r.mu.Lock()
+	_, ok := r.retrying[i]
+	delete(r.retrying, i)
+	n := len(r.retrying)
+	r.mu.Unlock()
+	if m, isRetry := r.metrics.(RetryMetrics); ok && isRetry {
+		m.SetRetrying(n)
+	}
+}
+
+func (r *retrier) status() []RetryStatus {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]RetryStatus, 0, len(r.retrying))
+	for _, i := range slices.Sorted(maps.Keys(r.retrying)) {
+		statuses = append(statuses, r.retrying[i])
+	}
+	return statuses
+}
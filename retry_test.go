@@ -0,0 +1,176 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+// flakyRetriever fails the first failures retrievals.
+type flakyRetriever struct {
+	retriever.Retriever
+	failures int32
+	calls    atomic.Int32
+}
+
+func (r *flakyRetriever) Retrieve(ctx context.Context) ([]byte, error) {
+	if r.calls.Add(1) <= r.failures {
+		return nil, errors.New("unavailable")
+	}
+	return r.Retriever.Retrieve(ctx)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Jitter: 0.1}.withDefaults()
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 4, want: 5 * time.Second},
+		{attempt: 10, want: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		got := p.backoff(tt.attempt)
+		low, high := time.Duration(float64(tt.want)*0.9), time.Duration(float64(tt.want)*1.1)
+		if got < low || got > high {
+			t.Errorf("attempt %d: expected backoff within [%s, %s], got %s", tt.attempt, low, high, got)
+		}
+	}
+}
+
+func TestRetrier(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		failures    int32
+		wantErr     bool
+		wantCalls   int32
+		wantRetries int
+	}{
+		{name: "first attempt", failures: 0, wantCalls: 1},
+		{name: "recovers", failures: 2, wantCalls: 3, wantRetries: 2},
+		{name: "gives up", failures: 10, wantErr: true, wantCalls: 3, wantRetries: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			reg := NewRegistry()
+			r := newRetrier(RetryPolicy{MaxAttempts: 3}, reg, newLimitedLogger(nil))
+			var statuses [][]RetryStatus
+			r.sleep = func(context.Context, time.Duration) error {
+				statuses = append(statuses, r.status())
+				return nil
+			}
+			flaky := &flakyRetriever{
+				Retriever: &fileretriever.Retriever{Path: yamlFlagFileName},
+				failures:  tt.failures,
+			}
+
+			_, err := r.wrap([]retriever.Retriever{flaky})[0].Retrieve(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got := flaky.calls.Load(); got != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+			if len(statuses) != tt.wantRetries {
+				t.Fatalf("expected %d retries, got %d", tt.wantRetries, len(statuses))
+			}
+			for i, s := range statuses {
+				if len(s) != 1 || s[0].Attempt != i+1 || s[0].LastError != "unavailable" {
+					t.Errorf("unexpected retry status while backing off: %+v", s)
+				}
+			}
+			if s := r.status(); len(s) != 0 {
+				t.Errorf("expected no retry status once done, got %+v", s)
+			}
+			out := scrape(t, reg)
+			for _, want := range []string{"flags_retrying 0", fmt.Sprintf("flags_retries_total %d", tt.wantRetries)} {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected %q in metrics:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRetrierStopsOnContext(t *testing.T) {
+	t.Parallel()
+	r := newRetrier(RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour}, nil, newLimitedLogger(nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.retrieve(ctx, 0, failingRetriever{}.Retrieve)
+	if err == nil {
+		t.Errorf("expected an error once the context is done")
+	}
+}
+
+func TestNewClientRetry(t *testing.T) {
+	// a failed init in an earlier test leaves the singleton's once spent
+	ffclient.Close()
+	flaky := &flakyRetriever{
+		Retriever: &fileretriever.Retriever{Path: yamlFlagFileName},
+		failures:  2,
+	}
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{flaky},
+		Retry:               &RetryPolicy{InitialBackoff: time.Millisecond},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("expected the retries to recover, got %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got == 7 {
+		t.Errorf("expected the loaded flag, got %d, %v", got, err)
+	}
+	rec := httptest.NewRecorder()
+	HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var body struct{ Status string }
+	if err = json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error decoding health: %v", err)
+	}
+	if rec.Code != http.StatusOK || body.Status != "ok" {
+		t.Errorf("expected ok health, got %d %s", rec.Code, body.Status)
+	}
+}
+
+func TestNewClientRetryValidatesOverrides(t *testing.T) {
+	ffclient.Close()
+	flaky := &flakyRetriever{
+		Retriever: &fileretriever.Retriever{Path: yamlFlagFileName},
+		failures:  1,
+	}
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{flaky},
+		Retry:               &RetryPolicy{InitialBackoff: time.Millisecond},
+		Overrides:           []Evaluator{parseFeatures(t, "--feature", "ff-number=42")},
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("expected the override validation to retry, got %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 42 {
+		t.Errorf("unexpected value: got %d, %v want 42", got, err)
+	}
+}
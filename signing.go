@@ -0,0 +1,123 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+)
+
+// SignatureExt is appended to a flag file's path for its detached signature.
+const SignatureExt = ".sig"
+
+var (
+	ErrUnsigned         = errors.New("flag file is not signed")
+	ErrInvalidSignature = errors.New("flag file signature is not valid for any trusted key")
+)
+
+// SignFlagFile returns the detached signature of doc, base64 encoded.
+func SignFlagFile(key ed25519.PrivateKey, doc []byte) []byte {
+	sig := ed25519.Sign(key, doc)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
+
+// VerifyFlagFile checks sig against each of keys, so a key can be rotated by
+// trusting the old and new key until every file is signed with the new one.
+func VerifyFlagFile(doc, sig []byte, keys []ed25519.PublicKey) error {
+	sig = bytes.TrimSpace(sig)
+	if len(sig) == 0 {
+		return ErrUnsigned
+	}
+	raw, err := base64.StdEncoding.DecodeString(string(sig))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	for _, k := range keys {
+		if ed25519.Verify(k, doc, raw) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// ParsePublicKey parses a base64 encoded ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace([]byte(s))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key length %d", len(b))
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey parses a base64 encoded ed25519 private key.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace([]byte(s))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key length %d", len(b))
+	}
+	return ed25519.PrivateKey(b), nil
+}
+
+// NewVerifyingRetriever only passes on flags from r whose detached signature,
+// read from sig, verifies against one of keys. Anything else fails the
+// retrieval, so goff keeps the flags it has. Flags persisted to
+// Config.CacheFile keep their signature and are verified again before
+// they're served from it.
+func NewVerifyingRetriever(r, sig retriever.Retriever, keys ...ed25519.PublicKey) retriever.Retriever {
+	v := &verification{keys: keys}
+	return wrapLayer(r, v, func(next retrieveFunc) retrieveFunc {
+		return func(ctx context.Context) ([]byte, error) {
+			doc, err := next(ctx)
+			if err != nil {
+				return nil, err
+			}
+			s, err := sig.Retrieve(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("%w: failed to retrieve signature: %v", ErrUnsigned, err)
+			}
+			if err = v.verify(doc, s); err != nil {
+				return nil, fmt.Errorf("failed to verify flag file: %w", err)
+			}
+			v.mu.Lock()
+			v.doc, v.sig = doc, s
+			v.mu.Unlock()
+			return doc, nil
+		}
+	})
+}
+
+// verification keeps the last flag file a verifying retriever passed on
+// with its signature.
+type verification struct {
+	keys []ed25519.PublicKey
+
+	mu  sync.Mutex
+	doc []byte
+	sig []byte
+}
+
+func (v *verification) verify(doc, sig []byte) error {
+	return VerifyFlagFile(doc, sig, v.keys)
+}
+
+// signature returns the signature doc was verified with, nil if it's not
+// the last flag file passed on.
+func (v *verification) signature(doc []byte) []byte {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !bytes.Equal(v.doc, doc) {
+		return nil
+	}
+	return v.sig
+}
@@ -0,0 +1,192 @@
+package flags
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	return pub, priv
+}
+
+func TestVerifyFlagFile(t *testing.T) {
+	t.Parallel()
+	oldPub, oldPriv := newKey(t)
+	newPub, newPriv := newKey(t)
+	_, otherPriv := newKey(t)
+	doc := []byte(bootstrapFlags)
+	tests := []struct {
+		name    string
+		doc     []byte
+		sig     []byte
+		wantErr error
+	}{
+		{name: "old key", doc: doc, sig: SignFlagFile(oldPriv, doc)},
+		{name: "new key", doc: doc, sig: SignFlagFile(newPriv, doc)},
+		{name: "untrusted key", doc: doc, sig: SignFlagFile(otherPriv, doc), wantErr: ErrInvalidSignature},
+		{name: "tampered", doc: append([]byte("evil: {}\n"), doc...), sig: SignFlagFile(oldPriv, doc), wantErr: ErrInvalidSignature},
+		{name: "garbage", doc: doc, sig: []byte("not base64!"), wantErr: ErrInvalidSignature},
+		{name: "unsigned", doc: doc, wantErr: ErrUnsigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := VerifyFlagFile(tt.doc, tt.sig, []ed25519.PublicKey{oldPub, newPub})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyingRetriever(t *testing.T) {
+	t.Parallel()
+	pub, priv := newKey(t)
+	_, otherPriv := newKey(t)
+	tests := []struct {
+		name    string
+		sig     func(doc []byte) []byte
+		wantErr error
+	}{
+		{name: "signed", sig: func(doc []byte) []byte { return SignFlagFile(priv, doc) }},
+		{name: "wrong key", sig: func(doc []byte) []byte { return SignFlagFile(otherPriv, doc) }, wantErr: ErrInvalidSignature},
+		{name: "no signature file", wantErr: ErrUnsigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "flags.goff.yaml")
+			writeFile(t, path, bootstrapFlags)
+			if tt.sig != nil {
+				writeFile(t, path+SignatureExt, string(tt.sig([]byte(bootstrapFlags))))
+			}
+			r := NewVerifyingRetriever(
+				&fileretriever.Retriever{Path: path},
+				&fileretriever.Retriever{Path: path + SignatureExt},
+				pub,
+			)
+			b, err := r.Retrieve(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && string(b) != bootstrapFlags {
+				t.Errorf("unexpected flags %q", b)
+			}
+		})
+	}
+}
+
+func TestNewClientVerifyingRetriever(t *testing.T) {
+	pub, _ := newKey(t)
+	path := filepath.Join(t.TempDir(), "flags.goff.yaml")
+	writeFile(t, path, bootstrapFlags)
+	writeFile(t, path+SignatureExt, "")
+	err := NewClient(Config{
+		PollingInterval: 10 * time.Second,
+		Retrievers: []retriever.Retriever{NewVerifyingRetriever(
+			&fileretriever.Retriever{Path: path},
+			&fileretriever.Retriever{Path: path + SignatureExt},
+			pub,
+		)},
+		DisableEnvOverrides: true,
+	})
+	if err == nil {
+		Close()
+		t.Fatal("expected an unsigned flag file to fail NewClient")
+	}
+}
+
+func TestNewClientVerifyingRetrieverCacheFile(t *testing.T) {
+	pub, priv := newKey(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flags.goff.yaml")
+	writeFile(t, path, bootstrapFlags)
+	writeFile(t, path+SignatureExt, string(SignFlagFile(priv, []byte(bootstrapFlags))))
+	verifying := func(path string) []retriever.Retriever {
+		return []retriever.Retriever{NewVerifyingRetriever(
+			&fileretriever.Retriever{Path: path},
+			&fileretriever.Retriever{Path: path + SignatureExt},
+			pub,
+		)}
+	}
+	cache := filepath.Join(dir, "flags.cache")
+	ffclient.Close()
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          verifying(path),
+		CacheFile:           cache,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	Close()
+	docs, sigs, err := readCacheFile(cache, "yaml")
+	if err != nil {
+		t.Fatalf("unexpected error reading cache: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		doc       string
+		expectErr bool
+	}{
+		{name: "signed", doc: docs[0]},
+		// the checksum only catches corruption, anyone who can write the
+		// cache can recompute it
+		{name: "tampered", doc: docs[0] + yamlFlag("evil", 1), expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := writeCacheFile(cache, "yaml", map[int]string{0: tt.doc}, sigs, time.Now()); err != nil {
+				t.Fatalf("unexpected error writing cache: %v", err)
+			}
+			ffclient.Close()
+			err := NewClient(Config{
+				PollingInterval:     10 * time.Second,
+				Retrievers:          verifying(filepath.Join(dir, "missing.yaml")),
+				CacheFile:           cache,
+				DisableEnvOverrides: true,
+			})
+			if err == nil {
+				defer Close()
+			}
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected a tampered cache not to be served")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the cache to be served, got %v", err)
+			}
+			if got, err := GetInt(numberFlagName, "user", 7); err != nil || got != 1 {
+				t.Errorf("unexpected value: got %d, %v want 1", got, err)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	t.Parallel()
+	if _, err := ParsePublicKey("c2hvcnQ="); err == nil {
+		t.Errorf("expected a short public key to fail")
+	}
+	if _, err := ParsePrivateKey("not base64!"); err == nil {
+		t.Errorf("expected invalid base64 to fail")
+	}
+}
@@ -0,0 +1,155 @@
+package flags
+
+import (
+	"errors"
+	"time"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/model"
+)
+
+// ReasonStale is reported for flags served under StaleDefault or
+// StaleFailClosed once the flags are older than Config.MaxStaleness.
+const ReasonStale = "STALE"
+
+const errorCodeStale = "STALE"
+
+var ErrStale = errors.New("flags are stale")
+
+// StalePolicy is what a flag serves once the last successful refresh is
+// older than Config.MaxStaleness.
+type StalePolicy int
+
+const (
+	// StaleServe keeps serving the last loaded values
+	StaleServe StalePolicy = iota
+	// StaleDefault serves the default passed to the getter
+	StaleDefault
+	// StaleFailClosed serves the zero value, e.g. false for IsEnabled,
+	// whatever the default passed to the getter
+	StaleFailClosed
+)
+
+// staleness applies the stale policies to the evaluator it wraps.
+type staleness struct {
+	Evaluator
+	maxAge      time.Duration
+	policy      StalePolicy
+	flags       map[string]StalePolicy
+	refreshDate func() time.Time
+	now         func() time.Time
+	// started is when the client was created, the age of flags that never
+	// loaded
+	started time.Time
+}
+
+// newStaleness measures the age of the flags from refreshDate, the last
+// time every retriever succeeded.
+func newStaleness(base Evaluator, cfg Config, refreshDate func() time.Time) *staleness {
+	return &staleness{
+		Evaluator:   base,
+		maxAge:      cfg.MaxStaleness,
+		policy:      cfg.StalePolicy,
+		flags:       cfg.StaleFlags,
+		refreshDate: refreshDate,
+		now:         time.Now,
+		started:     time.Now(),
+	}
+}
+
+func (s *staleness) unwrap() Evaluator {
+	return s.Evaluator
+}
+
+// stale reports whether the flags are older than the max staleness, with
+// the time of the last successful refresh.
+func (s *staleness) stale() (bool, time.Time) {
+	if s == nil {
+		return false, time.Time{}
+	}
+	last := s.refreshDate()
+	if last.IsZero() {
+		// nothing loaded yet, count from the start so a client that never
+		// loads becomes stale too
+		return s.now().Sub(s.started) > s.maxAge, last
+	}
+	return s.now().Sub(last) > s.maxAge, last
+}
+
+func (s *staleness) policyFor(flag string) StalePolicy {
+	if p, ok := s.flags[flag]; ok {
+		return p
+	}
+	return s.policy
+}
+
+func applyStaleness[T model.JSONType](
+	s *staleness,
+	flag string,
+	defaultValue T,
+	eval func() (model.VariationResult[T], error),
+) (model.VariationResult[T], error) {
+	p := s.policyFor(flag)
+	if p == StaleServe {
+		return eval()
+	}
+	if stale, _ := s.stale(); !stale {
+		return eval()
+	}
+	if p == StaleFailClosed {
+		var zero T
+		defaultValue = zero
+	}
+	res := defaultResult(defaultValue, errorCodeStale)
+	res.Reason = ReasonStale
+	return res, ErrStale
+}
+
+func (s *staleness) BoolVariationDetails(flag string, ctx ffcontext.Context, defaultValue bool) (model.VariationResult[bool], error) {
+	return applyStaleness(s, flag, defaultValue, func() (model.VariationResult[bool], error) {
+		return s.Evaluator.BoolVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (s *staleness) IntVariationDetails(flag string, ctx ffcontext.Context, defaultValue int) (model.VariationResult[int], error) {
+	return applyStaleness(s, flag, defaultValue, func() (model.VariationResult[int], error) {
+		return s.Evaluator.IntVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (s *staleness) Float64VariationDetails(flag string, ctx ffcontext.Context, defaultValue float64) (model.VariationResult[float64], error) {
+	return applyStaleness(s, flag, defaultValue, func() (model.VariationResult[float64], error) {
+		return s.Evaluator.Float64VariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (s *staleness) StringVariationDetails(flag string, ctx ffcontext.Context, defaultValue string) (model.VariationResult[string], error) {
+	return applyStaleness(s, flag, defaultValue, func() (model.VariationResult[string], error) {
+		return s.Evaluator.StringVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (s *staleness) JSONVariationDetails(flag string, ctx ffcontext.Context, defaultValue map[string]any) (model.VariationResult[map[string]any], error) {
+	return applyStaleness(s, flag, defaultValue, func() (model.VariationResult[map[string]any], error) {
+		return s.Evaluator.JSONVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (s *staleness) JSONArrayVariationDetails(flag string, ctx ffcontext.Context, defaultValue []any) (model.VariationResult[[]any], error) {
+	return applyStaleness(s, flag, defaultValue, func() (model.VariationResult[[]any], error) {
+		return s.Evaluator.JSONArrayVariationDetails(flag, ctx, defaultValue)
+	})
+}
+
+func (s *staleness) ForceRefresh() bool {
+	if r, ok := s.Evaluator.(refresher); ok {
+		return r.ForceRefresh()
+	}
+	return false
+}
+
+func (s *staleness) Close() {
+	if c, ok := s.Evaluator.(closer); ok {
+		c.Close()
+	}
+}
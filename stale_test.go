@@ -0,0 +1,148 @@
+package flags
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+func TestStaleness(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		lastRefresh time.Time
+		policy      StalePolicy
+		flags       map[string]StalePolicy
+		want        bool
+		wantErr     error
+	}{
+		{name: "fresh", lastRefresh: now.Add(-time.Minute), policy: StaleFailClosed, want: true},
+		{name: "stale serve", lastRefresh: now.Add(-time.Hour), policy: StaleServe, want: true},
+		{name: "stale default", lastRefresh: now.Add(-time.Hour), policy: StaleDefault, want: true, wantErr: ErrStale},
+		{name: "stale fail closed", lastRefresh: now.Add(-time.Hour), policy: StaleFailClosed, want: false, wantErr: ErrStale},
+		{
+			name:        "per flag policy",
+			lastRefresh: now.Add(-time.Hour),
+			policy:      StaleServe,
+			flags:       map[string]StalePolicy{isEnabledFlagName: StaleFailClosed},
+			want:        false,
+			wantErr:     ErrStale,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newStaleness(NewInMemory(map[string]any{isEnabledFlagName: true}), Config{
+				MaxStaleness: 10 * time.Minute,
+				StalePolicy:  tt.policy,
+				StaleFlags:   tt.flags,
+			}, func() time.Time { return tt.lastRefresh })
+			s.now = func() time.Time { return now }
+
+			res, err := s.BoolVariationDetails(isEnabledFlagName, ffcontext.NewEvaluationContext("user"), true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if res.Value != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, res.Value)
+			}
+			if tt.wantErr != nil && res.Reason != ReasonStale {
+				t.Errorf("expected reason %s, got %s", ReasonStale, res.Reason)
+			}
+		})
+	}
+}
+
+func TestStalenessNeverLoaded(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newStaleness(NewInMemory(nil), Config{MaxStaleness: time.Minute}, func() time.Time { return time.Time{} })
+	s.now = func() time.Time { return now }
+	s.started = now
+
+	if stale, _ := s.stale(); stale {
+		t.Errorf("expected not stale straight away")
+	}
+	now = now.Add(2 * time.Minute)
+	if stale, _ := s.stale(); !stale {
+		t.Errorf("expected stale once max staleness passed without a refresh")
+	}
+
+	t.Run("counts from creation, not the first check", func(t *testing.T) {
+		t.Parallel()
+		s := newStaleness(NewInMemory(nil), Config{MaxStaleness: time.Minute}, func() time.Time { return time.Time{} })
+		s.now = func() time.Time { return s.started.Add(2 * time.Minute) }
+		if stale, _ := s.stale(); !stale {
+			t.Errorf("expected stale on the first check after max staleness")
+		}
+	})
+}
+
+func TestHealthHandlerDegraded(t *testing.T) {
+	s := newStaleness(NewInMemory(nil), Config{MaxStaleness: time.Minute}, func() time.Time { return time.Now().Add(-time.Hour) })
+	st := newStartup()
+	st.stale = s
+	begin(st)
+	defer Close()
+
+	rec := httptest.NewRecorder()
+	HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var body struct {
+		Status string
+		Stale  bool
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error decoding health: %v", err)
+	}
+	if body.Status != "degraded" || !body.Stale {
+		t.Errorf("expected degraded and stale, got %+v", body)
+	}
+}
+
+func TestNewClientStaleCacheFile(t *testing.T) {
+	ffclient.Close()
+	path := filepath.Join(t.TempDir(), "flags.cache")
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&fileretriever.Retriever{Path: yamlFlagFileName}},
+		CacheFile:           path,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	Close()
+
+	// goff refreshes every second from the cache, which must not count as
+	// a successful refresh
+	err = NewClient(Config{
+		PollingInterval:     time.Second,
+		Retrievers:          []retriever.Retriever{failingRetriever{}},
+		CacheFile:           path,
+		MaxStaleness:        1500 * time.Millisecond,
+		StalePolicy:         StaleDefault,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("expected the cache to be used, got %v", err)
+	}
+	defer Close()
+
+	if got, err := GetInt(numberFlagName, "user", 7); err != nil || got == 7 {
+		t.Fatalf("expected the cached flag before max staleness, got %d, %v", got, err)
+	}
+	time.Sleep(2500 * time.Millisecond)
+	if got, err := GetInt(numberFlagName, "user", 7); !errors.Is(err, ErrStale) || got != 7 {
+		t.Errorf("expected the default once stale, got %d, %v", got, err)
+	}
+}
@@ -0,0 +1,59 @@
+package flags
+
+import (
+	"context"
+	"strings"
+
+	"github.com/thomaspoignant/go-feature-flag/ffcontext"
+)
+
+const providerName = "go-feature-flag"
+
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Tracer receives an event for every evaluation, named and attributed per
+// the OpenTelemetry feature_flag semantic conventions. With OTel it adapts as
+//
+//	func (otelTracer) AddEvent(ctx context.Context, name string, attrs []flags.Attribute) {
+//		kv := make([]attribute.KeyValue, 0, len(attrs))
+//		for _, a := range attrs {
+//			kv = append(kv, attribute.String(a.Key, a.Value))
+//		}
+//		trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(kv...))
+//	}
+type Tracer interface {
+	AddEvent(ctx context.Context, name string, attrs []Attribute)
+}
+
+func traceEvaluation(
+	ctx context.Context,
+	t Tracer,
+	flag string,
+	c ffcontext.Context,
+	variant,
+	reason,
+	errorCode string,
+	err error,
+) {
+	attrs := []Attribute{
+		{Key: "feature_flag.key", Value: flag},
+		{Key: "feature_flag.provider.name", Value: providerName},
+		{Key: "feature_flag.context.id", Value: c.GetKey()},
+	}
+	if variant != "" {
+		attrs = append(attrs, Attribute{Key: "feature_flag.result.variant", Value: variant})
+	}
+	if reason != "" {
+		attrs = append(attrs, Attribute{Key: "feature_flag.result.reason", Value: strings.ToLower(reason)})
+	}
+	if err != nil {
+		attrs = append(attrs,
+			Attribute{Key: "error.type", Value: errorKind(errorCode)},
+			Attribute{Key: "error.message", Value: err.Error()},
+		)
+	}
+	t.AddEvent(ctx, "feature_flag.evaluation", attrs)
+}
@@ -0,0 +1,82 @@
+package flags
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type ctxKey struct{}
+
+type event struct {
+	span  any
+	name  string
+	attrs []Attribute
+}
+
+type fakeTracer struct {
+	mu     sync.Mutex
+	events []event
+}
+
+func (f *fakeTracer) AddEvent(ctx context.Context, name string, attrs []Attribute) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event{span: ctx.Value(ctxKey{}), name: name, attrs: attrs})
+}
+
+func TestTracer(t *testing.T) {
+	tr := &fakeTracer{}
+	err := NewClient(Config{
+		Evaluator:           NewInMemory(map[string]any{numberFlagName: 42}),
+		Tracer:              tr,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "span-1")
+	if _, err = GetIntContext(ctx, numberFlagName, "1", 69); err != nil {
+		t.Fatalf("unexpected error getting flag value: %v", err)
+	}
+	if _, err = IsEnabledContext(ctx, notExistsFlagName, "", false); err == nil {
+		t.Fatalf("expected error but got nil")
+	}
+
+	if len(tr.events) != 2 {
+		t.Fatalf("unexpected events: got %d want %d", len(tr.events), 2)
+	}
+	want := []event{
+		{
+			span: "span-1",
+			name: "feature_flag.evaluation",
+			attrs: []Attribute{
+				{Key: "feature_flag.key", Value: numberFlagName},
+				{Key: "feature_flag.provider.name", Value: providerName},
+				{Key: "feature_flag.context.id", Value: "1"},
+				{Key: "feature_flag.result.variant", Value: variationStatic},
+				{Key: "feature_flag.result.reason", Value: "static"},
+			},
+		},
+		{
+			span: "span-1",
+			name: "feature_flag.evaluation",
+			attrs: []Attribute{
+				{Key: "feature_flag.key", Value: notExistsFlagName},
+				{Key: "feature_flag.provider.name", Value: providerName},
+				{Key: "feature_flag.context.id", Value: "anonymous"},
+				{Key: "feature_flag.result.variant", Value: variationSDK},
+				{Key: "feature_flag.result.reason", Value: "error"},
+				{Key: "error.type", Value: "flag_not_found"},
+				{Key: "error.message", Value: "flag not-exists is not present or disabled"},
+			},
+		},
+	}
+	if diff := cmp.Diff(tr.events, want, cmp.AllowUnexported(event{})); diff != "" {
+		t.Errorf("unexpected events (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,278 @@
+package flags
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WatchDir is a directory whose files are watched, files added or removed
+// count as changes too.
+type WatchDir struct {
+	Path string
+	// Recursive watches the subdirectories too, including new ones
+	Recursive bool
+}
+
+type FileWatcherConfig struct {
+	Paths    []string
+	Dirs     []WatchDir
+	OnChange func()
+	// Debounce waits for a burst of file events to settle. Default: 50ms
+	Debounce time.Duration
+	// PollInterval is used when file events aren't supported or
+	// ForcePolling is set. Default: 1s
+	PollInterval time.Duration
+	ForcePolling bool
+	// Logger reports file events that can't be watched, the watcher polls
+	// instead. Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// FileWatcher calls OnChange when any of its files change. It watches the
+// parent directories, through inotify on Linux, so atomic rename writes and
+// Kubernetes ConfigMap symlink swaps are seen, then compares each file to
+// its last state so only real changes are reported. Elsewhere, or if the
+// directories can't be watched or the file events stop, it polls the files'
+// mtime and size.
+type FileWatcher struct {
+	paths    []string
+	dirs     []WatchDir
+	onChange func()
+	debounce time.Duration
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu    sync.Mutex
+	state map[string]os.FileInfo
+
+	// add watches a new subdirectory of a recursive WatchDir, watched has
+	// the directories already watched
+	add     func(dir string) error
+	watched map[string]bool
+
+	done   chan struct{}
+	wg     sync.WaitGroup
+	closer func() error
+	once   sync.Once
+}
+
+func NewFileWatcher(cfg FileWatcherConfig) (*FileWatcher, error) {
+	if len(cfg.Paths) == 0 && len(cfg.Dirs) == 0 {
+		return nil, errors.New("file watcher expects at least 1 path or dir")
+	}
+	if cfg.OnChange == nil {
+		return nil, errors.New("file watcher expects an OnChange func")
+	}
+	debounce := 50 * time.Millisecond
+	if cfg.Debounce > 0 {
+		debounce = cfg.Debounce
+	}
+	interval := time.Second
+	if cfg.PollInterval > 0 {
+		interval = cfg.PollInterval
+	}
+	logger := slog.Default()
+	if cfg.Logger != nil {
+		logger = cfg.Logger
+	}
+	w := &FileWatcher{
+		paths:    cfg.Paths,
+		dirs:     cfg.Dirs,
+		onChange: cfg.OnChange,
+		debounce: debounce,
+		interval: interval,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+	state, walked := w.snapshot()
+	w.state = state
+
+	var events <-chan struct{}
+	if !cfg.ForcePolling {
+		dirs := make([]string, 0, len(w.paths)+len(w.dirs)+len(walked))
+		for _, p := range w.paths {
+			dirs = append(dirs, filepath.Dir(p))
+		}
+		for _, d := range w.dirs {
+			dirs = append(dirs, d.Path)
+		}
+		dirs = append(dirs, walked...)
+		slices.Sort(dirs)
+		dirs = slices.Compact(dirs)
+		dw, err := watchDirs(dirs)
+		switch {
+		case err == nil:
+			events, w.add, w.closer = dw.events, dw.add, dw.close
+			w.watched = map[string]bool{}
+			for _, d := range dirs {
+				w.watched[d] = true
+			}
+		case !errors.Is(err, errors.ErrUnsupported):
+			// e.g. a directory that doesn't exist yet or too many watches
+			logger.Warn("failed to watch flag files, polling them instead", "error", err)
+		}
+	}
+	w.start(events)
+	return w, nil
+}
+
+// dirWatch sends on events whenever anything in its directories changes.
+type dirWatch struct {
+	events <-chan struct{}
+	add    func(dir string) error
+	close  func() error
+}
+
+// start watches for events, polling instead if there are none.
+func (w *FileWatcher) start(events <-chan struct{}) {
+	w.wg.Add(1)
+	go w.run(events)
+}
+
+func (w *FileWatcher) run(events <-chan struct{}) {
+	defer w.wg.Done()
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+	var ticker *time.Ticker
+	var poll <-chan time.Time
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	if events == nil {
+		ticker = time.NewTicker(w.interval)
+		poll = ticker.C
+	}
+	for {
+		select {
+		case <-w.done:
+			return
+		case _, ok := <-events:
+			if !ok {
+				// the event reader stopped, e.g. reading inotify failed,
+				// poll from now on and catch up on anything missed. Close
+				// stops it too, which isn't a failure
+				select {
+				case <-w.done:
+					return
+				default:
+				}
+				events = nil
+				ticker = time.NewTicker(w.interval)
+				poll = ticker.C
+				w.check()
+				continue
+			}
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			w.check()
+		case <-poll:
+			w.check()
+		}
+	}
+}
+
+// check calls OnChange once if any file differs from its last state, or
+// files were added to or removed from the dirs.
+func (w *FileWatcher) check() {
+	state, walked := w.snapshot()
+	if w.watchNew(walked) {
+		// catch anything written before the new directories were watched
+		state, _ = w.snapshot()
+	}
+	w.mu.Lock()
+	changed := !maps.EqualFunc(w.state, state, sameFile)
+	w.state = state
+	w.mu.Unlock()
+	if changed {
+		w.onChange()
+	}
+}
+
+// snapshot stats the paths and everything in the dirs, with the
+// directories it walked.
+func (w *FileWatcher) snapshot() (map[string]os.FileInfo, []string) {
+	state := make(map[string]os.FileInfo, len(w.paths))
+	for _, p := range w.paths {
+		state[p] = stat(p)
+	}
+	var walked []string
+	for _, d := range w.dirs {
+		_ = filepath.WalkDir(d.Path, func(p string, e fs.DirEntry, err error) error {
+			if err != nil {
+				// e.g. the directory doesn't exist yet, which its missing
+				// state already covers
+				return nil
+			}
+			if e.IsDir() {
+				if p != d.Path && !d.Recursive {
+					return fs.SkipDir
+				}
+				walked = append(walked, p)
+			}
+			state[p] = stat(p)
+			return nil
+		})
+	}
+	return state, walked
+}
+
+// watchNew watches directories that appeared since the last check,
+// reporting whether there were any. If they can't be watched the events
+// stop, so the watcher polls instead.
+func (w *FileWatcher) watchNew(dirs []string) bool {
+	if w.add == nil {
+		return false
+	}
+	added := false
+	for _, d := range dirs {
+		if w.watched[d] {
+			continue
+		}
+		if err := w.add(d); err != nil {
+			w.logger.Warn("failed to watch flag files, polling them instead", "error", err)
+			w.add = nil
+			_ = w.closer()
+			return added
+		}
+		w.watched[d] = true
+		added = true
+	}
+	return added
+}
+
+func (w *FileWatcher) Close() {
+	w.once.Do(func() {
+		close(w.done)
+		// after run returns, so a directory isn't added to a closed watch
+		w.wg.Wait()
+		if w.closer != nil {
+			_ = w.closer()
+		}
+	})
+}
+
+// stat follows symlinks, a missing file is nil.
+func stat(path string) os.FileInfo {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	return fi
+}
+
+func sameFile(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package flags
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB
+
+// watchDirs watches dirs through inotify. The events themselves aren't
+// parsed, the watcher stats its files instead.
+func watchDirs(dirs []string) (*dirWatch, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init inotify: %w", err)
+	}
+	add := func(d string) error {
+		if _, err := syscall.InotifyAddWatch(fd, d, inotifyMask); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", d, err)
+		}
+		return nil
+	}
+	for _, d := range dirs {
+		if err = add(d); err != nil {
+			syscall.Close(fd)
+			return nil, err
+		}
+	}
+	// a non blocking fd goes through the runtime poller, so Close unblocks
+	// the pending Read
+	f := os.NewFile(uintptr(fd), "inotify")
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return &dirWatch{events: events, add: add, close: f.Close}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package flags
+
+import "errors"
+
+func watchDirs([]string) (*dirWatch, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,389 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ffclient "github.com/thomaspoignant/go-feature-flag"
+	"github.com/thomaspoignant/go-feature-flag/retriever"
+	"github.com/thomaspoignant/go-feature-flag/retriever/fileretriever"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unexpected error writing %s: %v", path, err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFileWatcher(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		// setup returns the watched path
+		setup  func(t *testing.T, dir string) string
+		change func(t *testing.T, dir string)
+	}{
+		{
+			name: "write in place",
+			setup: func(t *testing.T, dir string) string {
+				writeFile(t, filepath.Join(dir, "flags.yaml"), "a")
+				return filepath.Join(dir, "flags.yaml")
+			},
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "flags.yaml"), "ab")
+			},
+		},
+		{
+			name: "atomic rename",
+			setup: func(t *testing.T, dir string) string {
+				writeFile(t, filepath.Join(dir, "flags.yaml"), "a")
+				return filepath.Join(dir, "flags.yaml")
+			},
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "flags.yaml.tmp"), "b")
+				if err := os.Rename(filepath.Join(dir, "flags.yaml.tmp"), filepath.Join(dir, "flags.yaml")); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name: "created",
+			setup: func(t *testing.T, dir string) string {
+				return filepath.Join(dir, "flags.yaml")
+			},
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "flags.yaml"), "a")
+			},
+		},
+		{
+			// how the kubelet updates a mounted ConfigMap
+			name: "configmap symlink swap",
+			setup: func(t *testing.T, dir string) string {
+				if err := os.Mkdir(filepath.Join(dir, "..v1"), 0o755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				writeFile(t, filepath.Join(dir, "..v1", "flags.yaml"), "a")
+				for _, link := range [][2]string{{"..v1", "..data"}, {"..data/flags.yaml", "flags.yaml"}} {
+					if err := os.Symlink(link[0], filepath.Join(dir, link[1])); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+				return filepath.Join(dir, "flags.yaml")
+			},
+			change: func(t *testing.T, dir string) {
+				if err := os.Mkdir(filepath.Join(dir, "..v2"), 0o755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				writeFile(t, filepath.Join(dir, "..v2", "flags.yaml"), "a")
+				if err := os.Symlink("..v2", filepath.Join(dir, "..data_tmp")); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, polling := range []bool{false, true} {
+			name := tt.name
+			if polling {
+				name += " polling"
+			}
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				dir := t.TempDir()
+				path := tt.setup(t, dir)
+				var calls atomic.Int32
+				w, err := NewFileWatcher(FileWatcherConfig{
+					Paths:        []string{path},
+					OnChange:     func() { calls.Add(1) },
+					Debounce:     10 * time.Millisecond,
+					PollInterval: 10 * time.Millisecond,
+					ForcePolling: polling,
+				})
+				if err != nil {
+					t.Fatalf("unexpected error creating watcher: %v", err)
+				}
+				defer w.Close()
+
+				// mtimes can be coarse, make sure the change is seen as one
+				time.Sleep(20 * time.Millisecond)
+				tt.change(t, dir)
+				waitFor(t, "the change", func() bool { return calls.Load() > 0 })
+			})
+		}
+	}
+}
+
+func TestFileWatcherDirs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		recursive bool
+		change    func(t *testing.T, dir string)
+		expected  bool
+	}{
+		{
+			name: "file added",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "b.goff.yaml"), "b")
+			},
+			expected: true,
+		},
+		{
+			name: "file edited",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "a.goff.yaml"), "ab")
+			},
+			expected: true,
+		},
+		{
+			name: "file removed",
+			change: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "a.goff.yaml")); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			expected: true,
+		},
+		{
+			name:      "file edited in a subdirectory",
+			recursive: true,
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "team", "c.goff.yaml"), "cd")
+			},
+			expected: true,
+		},
+		{
+			name: "subdirectory ignored unless recursive",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "team", "c.goff.yaml"), "cd")
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, polling := range []bool{false, true} {
+			name := tt.name
+			if polling {
+				name += " polling"
+			}
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				dir := t.TempDir()
+				writeFile(t, filepath.Join(dir, "a.goff.yaml"), "a")
+				if err := os.Mkdir(filepath.Join(dir, "team"), 0o755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				writeFile(t, filepath.Join(dir, "team", "c.goff.yaml"), "c")
+				var calls atomic.Int32
+				w, err := NewFileWatcher(FileWatcherConfig{
+					Dirs:         []WatchDir{{Path: dir, Recursive: tt.recursive}},
+					OnChange:     func() { calls.Add(1) },
+					Debounce:     10 * time.Millisecond,
+					PollInterval: 10 * time.Millisecond,
+					ForcePolling: polling,
+				})
+				if err != nil {
+					t.Fatalf("unexpected error creating watcher: %v", err)
+				}
+				defer w.Close()
+
+				time.Sleep(20 * time.Millisecond)
+				tt.change(t, dir)
+				if tt.expected {
+					waitFor(t, "the change", func() bool { return calls.Load() > 0 })
+					return
+				}
+				time.Sleep(100 * time.Millisecond)
+				if got := calls.Load(); got != 0 {
+					t.Errorf("expected no change, got %d", got)
+				}
+			})
+		}
+	}
+}
+
+func TestFileWatcherNewSubdirectory(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	var calls atomic.Int32
+	w, err := NewFileWatcher(FileWatcherConfig{
+		Dirs:     []WatchDir{{Path: dir, Recursive: true}},
+		OnChange: func() { calls.Add(1) },
+		Debounce: 10 * time.Millisecond,
+		// only the file events can see the write in time
+		PollInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating watcher: %v", err)
+	}
+	defer w.Close()
+
+	sub := filepath.Join(dir, "team")
+	if err = os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitFor(t, "the new directory", func() bool { return calls.Load() > 0 })
+	time.Sleep(20 * time.Millisecond)
+	before := calls.Load()
+	writeFile(t, filepath.Join(sub, "a.goff.yaml"), "a")
+	waitFor(t, "the file in the new directory", func() bool { return calls.Load() > before })
+}
+
+func TestFileWatcherDebounce(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	writeFile(t, path, "")
+	var calls atomic.Int32
+	w, err := NewFileWatcher(FileWatcherConfig{
+		Paths:    []string{path},
+		OnChange: func() { calls.Add(1) },
+		Debounce: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating watcher: %v", err)
+	}
+	defer w.Close()
+
+	for i := range 5 {
+		writeFile(t, path, strings.Repeat("a", i+1))
+	}
+	waitFor(t, "the change", func() bool { return calls.Load() > 0 })
+	time.Sleep(300 * time.Millisecond)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a burst of writes to be 1 change, got %d", got)
+	}
+}
+
+func TestNewClientWatchFiles(t *testing.T) {
+	ffclient.Close()
+	path := filepath.Join(t.TempDir(), "flags.goff.yaml")
+	writeFile(t, path, bootstrapFlags)
+	err := NewClient(Config{
+		PollingInterval:     time.Hour,
+		Retrievers:          []retriever.Retriever{&fileretriever.Retriever{Path: path}},
+		WatchFiles:          true,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, _ := GetInt(numberFlagName, "user", 7); got != 1 {
+		t.Fatalf("expected 1 before the change, got %d", got)
+	}
+	writeFile(t, path, strings.Replace(bootstrapFlags, "bootstrap: 1", "bootstrap: 2", 1))
+	waitFor(t, "the flag to reload", func() bool {
+		got, _ := GetInt(numberFlagName, "user", 7)
+		return got == 2
+	})
+}
+
+func TestNewClientWatchDir(t *testing.T) {
+	ffclient.Close()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.goff.yaml"), bootstrapFlags)
+	err := NewClient(Config{
+		PollingInterval:     time.Hour,
+		Retrievers:          []retriever.Retriever{&DirRetriever{Dir: dir}},
+		WatchFiles:          true,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+
+	if got, _ := GetString(descriptionFlagName, "user", "none"); got != "none" {
+		t.Fatalf("expected the default before the file is added, got %s", got)
+	}
+	writeFile(t, filepath.Join(dir, "b.goff.yaml"), `
+ff-description:
+  variations:
+    added: added
+  defaultRule:
+    variation: added
+`)
+	waitFor(t, "the new file to load", func() bool {
+		got, _ := GetString(descriptionFlagName, "user", "none")
+		return got == "added"
+	})
+}
+
+func TestFileWatcherEventsStop(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	writeFile(t, path, "a")
+	var calls atomic.Int32
+	w := &FileWatcher{
+		paths:    []string{path},
+		onChange: func() { calls.Add(1) },
+		debounce: 10 * time.Millisecond,
+		interval: 10 * time.Millisecond,
+		state:    map[string]os.FileInfo{path: stat(path)},
+		done:     make(chan struct{}),
+	}
+	events := make(chan struct{})
+	w.start(events)
+	defer w.Close()
+
+	close(events)
+	time.Sleep(20 * time.Millisecond)
+	writeFile(t, path, "ab")
+	waitFor(t, "the change to be polled", func() bool { return calls.Load() > 0 })
+}
+
+func TestFileWatcherMissingDir(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "later")
+	path := filepath.Join(dir, "flags.yaml")
+	var calls atomic.Int32
+	w, err := NewFileWatcher(FileWatcherConfig{
+		Paths:        []string{path},
+		OnChange:     func() { calls.Add(1) },
+		PollInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("expected a missing directory to be polled, got %v", err)
+	}
+	defer w.Close()
+
+	if err = os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writeFile(t, path, "a")
+	waitFor(t, "the new file to be polled", func() bool { return calls.Load() > 0 })
+}
+
+func TestNewClientWatchFilesMissingDir(t *testing.T) {
+	ffclient.Close()
+	err := NewClient(Config{
+		PollingInterval:     10 * time.Second,
+		Retrievers:          []retriever.Retriever{&fileretriever.Retriever{Path: filepath.Join(t.TempDir(), "later", "flags.yaml")}},
+		Bootstrap:           &fileretriever.Retriever{Path: yamlFlagFileName},
+		WatchFiles:          true,
+		DisableEnvOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	defer Close()
+}